@@ -0,0 +1,352 @@
+package snmp
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+type ValueType byte
+
+const (
+	TypeInteger        ValueType = 0x02
+	TypeOctetString    ValueType = 0x04
+	TypeNull           ValueType = 0x05
+	TypeObjectID       ValueType = 0x06
+	TypeIPAddress      ValueType = 0x40
+	TypeCounter32      ValueType = 0x41
+	TypeGauge32        ValueType = 0x42
+	TypeTimeTicks      ValueType = 0x43
+	TypeOpaque         ValueType = 0x44
+	TypeCounter64      ValueType = 0x46
+	TypeNoSuchObject   ValueType = 0x80
+	TypeNoSuchInstance ValueType = 0x81
+	TypeEndOfMibView   ValueType = 0x82
+)
+
+const tagSequence = 0x30
+
+// Variable is a decoded variable binding. Value holds an int64 for
+// INTEGER, a uint64 for Counter32, Gauge32, TimeTicks and Counter64, a
+// []byte for OCTET STRING and Opaque, a string for OBJECT IDENTIFIER, a
+// net.IP for IpAddress and nil otherwise.
+type Variable struct {
+	OID   string
+	Type  ValueType
+	Value interface{}
+}
+
+func (v Variable) Int() (int64, bool) {
+	switch value := v.Value.(type) {
+	case int64:
+		return value, true
+	case uint64:
+		return int64(value), true
+	default:
+		return 0, false
+	}
+}
+
+func (v Variable) Bytes() ([]byte, bool) {
+	value, ok := v.Value.([]byte)
+	return value, ok
+}
+
+func (v Variable) String() string {
+	switch value := v.Value.(type) {
+	case []byte:
+		return string(value)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+// decoder reads BER TLVs from data[pos:end] while keeping offsets absolute,
+// which USM needs to verify message digests in place.
+type decoder struct {
+	data []byte
+	pos  int
+	end  int
+}
+
+func newDecoder(data []byte) *decoder {
+	return &decoder{data: data, end: len(data)}
+}
+
+func (d *decoder) read() (byte, []byte, int, error) {
+	if d.pos+2 > d.end {
+		return 0, nil, 0, fmt.Errorf("truncated BER data")
+	}
+	tag := d.data[d.pos]
+	length := int(d.data[d.pos+1])
+	header := 2
+
+	if length&0x80 != 0 {
+		size := length & 0x7f
+		if size == 0 || size > 4 || d.pos+2+size > d.end {
+			return 0, nil, 0, fmt.Errorf("invalid BER length")
+		}
+		length = 0
+		for _, b := range d.data[d.pos+2 : d.pos+2+size] {
+			length = length<<8 | int(b)
+		}
+		header += size
+	}
+
+	start := d.pos + header
+	if length < 0 || start+length > d.end {
+		return 0, nil, 0, fmt.Errorf("truncated BER data")
+	}
+	d.pos = start + length
+	return tag, d.data[start : start+length], start, nil
+}
+
+func (d *decoder) expect(tag byte) ([]byte, int, error) {
+	got, value, offset, err := d.read()
+	if err != nil {
+		return nil, 0, err
+	}
+	if got != tag {
+		return nil, 0, fmt.Errorf("unexpected BER tag 0x%02x, want 0x%02x", got, tag)
+	}
+	return value, offset, nil
+}
+
+// enter reads a constructed TLV and returns a decoder over its contents.
+func (d *decoder) enter(tag byte) (*decoder, error) {
+	value, offset, err := d.expect(tag)
+	if err != nil {
+		return nil, err
+	}
+	return &decoder{data: d.data, pos: offset, end: offset + len(value)}, nil
+}
+
+func (d *decoder) integer() (int64, error) {
+	value, _, err := d.expect(byte(TypeInteger))
+	if err != nil {
+		return 0, err
+	}
+	return decodeInteger(value), nil
+}
+
+func (d *decoder) octetString() ([]byte, error) {
+	value, _, err := d.expect(byte(TypeOctetString))
+	return value, err
+}
+
+func (d *decoder) more() bool {
+	return d.pos < d.end
+}
+
+func decodeInteger(value []byte) int64 {
+	var result int64
+	for i, b := range value {
+		if i == 0 && b&0x80 != 0 {
+			result = -1
+		}
+		result = result<<8 | int64(b)
+	}
+	return result
+}
+
+func decodeUnsigned(value []byte) uint64 {
+	var result uint64
+	for _, b := range value {
+		result = result<<8 | uint64(b)
+	}
+	return result
+}
+
+func decodeOID(value []byte) (string, error) {
+	if len(value) == 0 {
+		return "", fmt.Errorf("empty object identifier")
+	}
+
+	parts := make([]string, 0, len(value)+1)
+	var sub uint64
+	first := true
+	for i, b := range value {
+		sub = sub<<7 | uint64(b&0x7f)
+		if b&0x80 != 0 {
+			if i == len(value)-1 {
+				return "", fmt.Errorf("truncated object identifier")
+			}
+			continue
+		}
+		if first {
+			x := sub / 40
+			if x > 2 {
+				x = 2
+			}
+			parts = append(parts, strconv.FormatUint(x, 10), strconv.FormatUint(sub-40*x, 10))
+			first = false
+		} else {
+			parts = append(parts, strconv.FormatUint(sub, 10))
+		}
+		sub = 0
+	}
+	return strings.Join(parts, "."), nil
+}
+
+func decodeVariable(d *decoder) (Variable, error) {
+	inner, err := d.enter(tagSequence)
+	if err != nil {
+		return Variable{}, err
+	}
+
+	oidValue, _, err := inner.expect(byte(TypeObjectID))
+	if err != nil {
+		return Variable{}, err
+	}
+	oid, err := decodeOID(oidValue)
+	if err != nil {
+		return Variable{}, err
+	}
+
+	tag, raw, _, err := inner.read()
+	if err != nil {
+		return Variable{}, err
+	}
+
+	variable := Variable{OID: oid, Type: ValueType(tag)}
+	switch variable.Type {
+	case TypeInteger:
+		variable.Value = decodeInteger(raw)
+	case TypeCounter32, TypeGauge32, TypeTimeTicks, TypeCounter64:
+		variable.Value = decodeUnsigned(raw)
+	case TypeOctetString, TypeOpaque:
+		variable.Value = append([]byte(nil), raw...)
+	case TypeObjectID:
+		variable.Value, err = decodeOID(raw)
+		if err != nil {
+			return Variable{}, err
+		}
+	case TypeIPAddress:
+		variable.Value = net.IP(append([]byte(nil), raw...))
+	}
+
+	return variable, nil
+}
+
+func encodeTLV(tag byte, value []byte) []byte {
+	length := len(value)
+	var header []byte
+	switch {
+	case length < 0x80:
+		header = []byte{tag, byte(length)}
+	case length <= 0xff:
+		header = []byte{tag, 0x81, byte(length)}
+	case length <= 0xffff:
+		header = []byte{tag, 0x82, byte(length >> 8), byte(length)}
+	default:
+		header = []byte{tag, 0x84, byte(length >> 24), byte(length >> 16), byte(length >> 8), byte(length)}
+	}
+	return append(header, value...)
+}
+
+func encodeSequence(tag byte, items ...[]byte) []byte {
+	var value []byte
+	for _, item := range items {
+		value = append(value, item...)
+	}
+	return encodeTLV(tag, value)
+}
+
+func encodeInteger(value int64) []byte {
+	var bytes []byte
+	for {
+		bytes = append([]byte{byte(value)}, bytes...)
+		if (value >= -128 && value < 128) || len(bytes) == 8 {
+			break
+		}
+		value >>= 8
+	}
+	return encodeTLV(byte(TypeInteger), bytes)
+}
+
+func encodeUnsigned(tag byte, value uint64) []byte {
+	var bytes []byte
+	for {
+		bytes = append([]byte{byte(value)}, bytes...)
+		value >>= 8
+		if value == 0 {
+			break
+		}
+	}
+	if bytes[0]&0x80 != 0 {
+		bytes = append([]byte{0}, bytes...)
+	}
+	return encodeTLV(tag, bytes)
+}
+
+func encodeOctetString(value []byte) []byte {
+	return encodeTLV(byte(TypeOctetString), value)
+}
+
+func encodeOID(oid string) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(oid, "."), ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("invalid object identifier %q", oid)
+	}
+
+	subs := make([]uint64, len(parts))
+	for i, part := range parts {
+		sub, err := strconv.ParseUint(part, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid object identifier %q", oid)
+		}
+		subs[i] = sub
+	}
+
+	value := encodeBase128(subs[0]*40 + subs[1])
+	for _, sub := range subs[2:] {
+		value = append(value, encodeBase128(sub)...)
+	}
+	return encodeTLV(byte(TypeObjectID), value), nil
+}
+
+func encodeBase128(value uint64) []byte {
+	bytes := []byte{byte(value & 0x7f)}
+	for value >>= 7; value > 0; value >>= 7 {
+		bytes = append([]byte{byte(value&0x7f) | 0x80}, bytes...)
+	}
+	return bytes
+}
+
+func encodeVariable(variable Variable) ([]byte, error) {
+	oid, err := encodeOID(variable.OID)
+	if err != nil {
+		return nil, err
+	}
+
+	var value []byte
+	switch variable.Type {
+	case TypeInteger:
+		number, _ := variable.Int()
+		value = encodeInteger(number)
+	case TypeCounter32, TypeGauge32, TypeTimeTicks, TypeCounter64:
+		number, _ := variable.Int()
+		value = encodeUnsigned(byte(variable.Type), uint64(number))
+	case TypeOctetString, TypeOpaque:
+		bytes, ok := variable.Bytes()
+		if !ok {
+			bytes = []byte(variable.String())
+		}
+		value = encodeTLV(byte(variable.Type), bytes)
+	case TypeObjectID:
+		value, err = encodeOID(variable.String())
+		if err != nil {
+			return nil, err
+		}
+	case TypeIPAddress:
+		ip, _ := variable.Value.(net.IP)
+		value = encodeTLV(byte(TypeIPAddress), ip.To4())
+	default:
+		value = encodeTLV(byte(variable.Type), nil)
+	}
+
+	return encodeSequence(tagSequence, oid, value), nil
+}
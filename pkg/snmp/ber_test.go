@@ -0,0 +1,97 @@
+package snmp
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestVariableRoundTrip(t *testing.T) {
+	variables := []Variable{
+		{OID: "1.3.6.1.2.1.1.1.0", Type: TypeOctetString, Value: []byte("MA5800-X7")},
+		{OID: "1.3.6.1.2.1.1.1.0", Type: TypeOctetString, Value: []byte{}},
+		{OID: "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.4.4194312960.0", Type: TypeInteger, Value: int64(-2155)},
+		{OID: "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.4.4194312960.1", Type: TypeInteger, Value: int64(2147483647)},
+		{OID: "1.3.6.1.4.1.2011.6.128.1.1.2.51.1.4.4194312960.2", Type: TypeInteger, Value: int64(-2147483648)},
+		{OID: "1.3.6.1.2.1.1.3.0", Type: TypeTimeTicks, Value: uint64(4294967295)},
+		{OID: "1.3.6.1.2.1.2.2.1.10.1", Type: TypeCounter32, Value: uint64(128)},
+		{OID: "1.3.6.1.2.1.31.1.1.1.6.1", Type: TypeCounter64, Value: uint64(1) << 40},
+		{OID: "1.3.6.1.2.1.2.2.1.5.1", Type: TypeGauge32, Value: uint64(1000000000)},
+		{OID: "1.3.6.1.6.3.1.1.4.1.0", Type: TypeObjectID, Value: "1.3.6.1.4.1.2011.6.128.1.1.0.1"},
+		{OID: "1.3.6.1.2.1.4.20.1.1.10.0.0.1", Type: TypeIPAddress, Value: net.IPv4(10, 0, 0, 1).To4()},
+		{OID: "1.3.6.1.2.1.1.5.0", Type: TypeNull},
+		{OID: "1.3.6.1.2.1.1.6.0", Type: TypeNoSuchInstance},
+		{OID: "1.3.6.1.2.1.1.7.0", Type: TypeEndOfMibView},
+	}
+
+	for _, variable := range variables {
+		encoded, err := encodeVariable(variable)
+		if err != nil {
+			t.Fatalf("%s: %v", variable.OID, err)
+		}
+		decoded, err := decodeVariable(newDecoder(encoded))
+		if err != nil {
+			t.Fatalf("%s: %v", variable.OID, err)
+		}
+		if decoded.OID != variable.OID || decoded.Type != variable.Type {
+			t.Fatalf("got %+v, want %+v", decoded, variable)
+		}
+		if ip, ok := variable.Value.(net.IP); ok {
+			if !ip.Equal(decoded.Value.(net.IP)) {
+				t.Fatalf("got %v, want %v", decoded.Value, ip)
+			}
+			continue
+		}
+		if value, ok := variable.Value.([]byte); ok {
+			if !bytes.Equal(decoded.Value.([]byte), value) {
+				t.Fatalf("got %v, want %v", decoded.Value, value)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(decoded.Value, variable.Value) {
+			t.Fatalf("%s: got %#v, want %#v", variable.OID, decoded.Value, variable.Value)
+		}
+	}
+}
+
+func TestEncodeInteger(t *testing.T) {
+	tests := map[int64][]byte{
+		0:    {0x02, 0x01, 0x00},
+		127:  {0x02, 0x01, 0x7f},
+		128:  {0x02, 0x02, 0x00, 0x80},
+		-1:   {0x02, 0x01, 0xff},
+		-129: {0x02, 0x02, 0xff, 0x7f},
+	}
+	for value, expected := range tests {
+		if encoded := encodeInteger(value); !bytes.Equal(encoded, expected) {
+			t.Fatalf("%d: got % x, want % x", value, encoded, expected)
+		}
+	}
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	packet := &Packet{
+		Version:   Version2c,
+		Community: "public",
+		PDU: PDU{
+			Type:        GetBulkRequest,
+			RequestID:   12345,
+			ErrorStatus: 0,
+			ErrorIndex:  25,
+			Variables:   []Variable{{OID: OIDGponOntOpticalRxPower, Type: TypeNull}},
+		},
+	}
+
+	data, err := packet.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Unmarshal(data, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded, packet) {
+		t.Fatalf("got %+v, want %+v", decoded, packet)
+	}
+}
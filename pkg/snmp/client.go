@@ -0,0 +1,289 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const OIDUsmStatsNotInTimeWindows = "1.3.6.1.6.3.15.1.1.2.0"
+
+type ClientOptions struct {
+	// Address is host or host:port; port 161 is used when omitted.
+	Address        string
+	Version        Version
+	Community      string
+	User           USMUser
+	ContextName    string
+	Timeout        time.Duration
+	Retries        int
+	MaxRepetitions int
+}
+
+// ResponseError is a non-zero error-status returned by the agent.
+type ResponseError struct {
+	Status int
+	Index  int
+}
+
+func (e ResponseError) Error() string {
+	return fmt.Sprintf("SNMP error status %d at variable %d", e.Status, e.Index)
+}
+
+// ReportError is an SNMPv3 report returned instead of a response, such as
+// an unknown user or a wrong digest.
+type ReportError struct {
+	OID string
+}
+
+func (e ReportError) Error() string {
+	return fmt.Sprintf("SNMPv3 report %s", e.OID)
+}
+
+type Client struct {
+	options   ClientOptions
+	conn      net.Conn
+	mu        sync.Mutex
+	requestID int32
+	engine    SecurityParameters
+	syncedAt  time.Time
+}
+
+func NewClient(options ClientOptions) (*Client, error) {
+	switch options.Version {
+	case Version2c:
+	case Version3:
+		if err := options.User.Validate(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported SNMP version %d", options.Version)
+	}
+
+	if _, _, err := net.SplitHostPort(options.Address); err != nil {
+		options.Address = net.JoinHostPort(options.Address, "161")
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = 2 * time.Second
+	}
+	if options.Retries < 0 {
+		options.Retries = 0
+	}
+	if options.MaxRepetitions <= 0 {
+		options.MaxRepetitions = 25
+	}
+
+	conn, err := net.Dial("udp", options.Address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", options.Address, err)
+	}
+
+	client := &Client{options: options, conn: conn, requestID: int32(time.Now().UnixNano() & 0x7fffffff)}
+	if options.Version == Version3 {
+		if err := client.discoverEngine(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) Get(oids ...string) ([]Variable, error) {
+	return c.request(GetRequest, 0, 0, oids)
+}
+
+func (c *Client) GetNext(oids ...string) ([]Variable, error) {
+	return c.request(GetNextRequest, 0, 0, oids)
+}
+
+func (c *Client) GetBulk(nonRepeaters, maxRepetitions int, oids ...string) ([]Variable, error) {
+	return c.request(GetBulkRequest, nonRepeaters, maxRepetitions, oids)
+}
+
+// Walk calls handle for every variable below root, in lexicographic order.
+func (c *Client) Walk(root string, handle func(Variable) error) error {
+	root = strings.TrimPrefix(root, ".")
+	next := root
+	for {
+		variables, err := c.GetBulk(0, c.options.MaxRepetitions, next)
+		if err != nil {
+			return err
+		}
+		if len(variables) == 0 {
+			return nil
+		}
+
+		for _, variable := range variables {
+			if variable.Type == TypeEndOfMibView || !strings.HasPrefix(variable.OID, root+".") {
+				return nil
+			}
+			if compareOID(variable.OID, next) <= 0 {
+				return fmt.Errorf("agent returned non-increasing OID %s after %s", variable.OID, next)
+			}
+			if err := handle(variable); err != nil {
+				return err
+			}
+			next = variable.OID
+		}
+	}
+}
+
+func (c *Client) request(pduType PDUType, errorStatus, errorIndex int, oids []string) ([]Variable, error) {
+	variables := make([]Variable, len(oids))
+	for i, oid := range oids {
+		variables[i] = Variable{OID: strings.TrimPrefix(oid, "."), Type: TypeNull}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resynced := false
+	for attempt := 0; attempt <= c.options.Retries; attempt++ {
+		c.requestID = (c.requestID + 1) & 0x7fffffff
+		packet := c.packet(PDU{Type: pduType, RequestID: c.requestID, ErrorStatus: errorStatus, ErrorIndex: errorIndex, Variables: variables})
+
+		response, err := c.exchange(packet)
+		if isTimeout(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if response.PDU.Type == Report {
+			oid := ""
+			if len(response.PDU.Variables) > 0 {
+				oid = response.PDU.Variables[0].OID
+			}
+			if oid == OIDUsmStatsNotInTimeWindows && !resynced {
+				c.setEngine(response.Security)
+				resynced = true
+				attempt--
+				continue
+			}
+			return nil, ReportError{OID: oid}
+		}
+
+		if response.PDU.ErrorStatus != 0 {
+			return nil, ResponseError{Status: response.PDU.ErrorStatus, Index: response.PDU.ErrorIndex}
+		}
+		return response.PDU.Variables, nil
+	}
+
+	return nil, fmt.Errorf("no response from %s after %d attempts", c.options.Address, c.options.Retries+1)
+}
+
+func (c *Client) packet(pdu PDU) *Packet {
+	packet := &Packet{Version: c.options.Version, Community: c.options.Community, PDU: pdu}
+	if c.options.Version == Version3 {
+		packet.MessageID = pdu.RequestID
+		packet.Reportable = true
+		packet.User = c.options.User
+		packet.Security = c.engine
+		packet.Security.EngineTime += int32(time.Since(c.syncedAt) / time.Second)
+		packet.ContextEngineID = c.engine.EngineID
+		packet.ContextName = c.options.ContextName
+	}
+	return packet
+}
+
+// exchange sends packet and waits for the message answering it, skipping
+// stray datagrams such as late replies to earlier attempts.
+func (c *Client) exchange(packet *Packet) (*Packet, error) {
+	data, err := packet.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := c.conn.Write(data); err != nil {
+		return nil, err
+	}
+
+	if err := c.conn.SetReadDeadline(time.Now().Add(c.options.Timeout)); err != nil {
+		return nil, err
+	}
+	buffer := make([]byte, maxMessageSize)
+	for {
+		n, err := c.conn.Read(buffer)
+		if err != nil {
+			return nil, err
+		}
+
+		response, err := Unmarshal(buffer[:n], []USMUser{c.options.User})
+		if err != nil {
+			continue
+		}
+		if packet.Version == Version3 {
+			if response.MessageID == packet.MessageID {
+				return response, nil
+			}
+		} else if response.PDU.RequestID == packet.PDU.RequestID {
+			return response, nil
+		}
+	}
+}
+
+// discoverEngine learns the agent's engine ID, boots and time from the
+// report answering an empty unauthenticated request (RFC 3414 section 4).
+func (c *Client) discoverEngine() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for attempt := 0; attempt <= c.options.Retries; attempt++ {
+		c.requestID = (c.requestID + 1) & 0x7fffffff
+		packet := &Packet{
+			Version:    Version3,
+			MessageID:  c.requestID,
+			Reportable: true,
+			PDU:        PDU{Type: GetRequest, RequestID: c.requestID},
+		}
+
+		response, err := c.exchange(packet)
+		if isTimeout(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if len(response.Security.EngineID) == 0 {
+			return fmt.Errorf("SNMPv3 engine discovery failed: empty engine ID")
+		}
+		c.setEngine(response.Security)
+		return nil
+	}
+
+	return fmt.Errorf("SNMPv3 engine discovery failed: no response from %s", c.options.Address)
+}
+
+func (c *Client) setEngine(security SecurityParameters) {
+	c.engine = security
+	c.syncedAt = time.Now()
+}
+
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+func compareOID(a, b string) int {
+	left, right := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(left) && i < len(right); i++ {
+		x, _ := strconv.ParseUint(left[i], 10, 32)
+		y, _ := strconv.ParseUint(right[i], 10, 32)
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(left) - len(right)
+}
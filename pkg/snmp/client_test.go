@@ -0,0 +1,109 @@
+package snmp
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+// startV3Agent answers SNMPv3 requests for testUser on a loopback port. It
+// reports the engine to discovery requests, answers the first authenticated
+// request with usmStatsNotInTimeWindows and a later engine time, and serves
+// variables after that. Every decoded request is sent on the returned
+// channel.
+func startV3Agent(t *testing.T, variables []Variable) (string, <-chan *Packet) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	requests := make(chan *Packet, 8)
+	engine := SecurityParameters{EngineID: []byte{0x80, 0x00, 0x07, 0xdb, 0x03, 0x02}, EngineBoots: 3, EngineTime: 100}
+
+	go func() {
+		buffer := make([]byte, maxMessageSize)
+		synced := false
+		for {
+			n, from, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			request, err := Unmarshal(buffer[:n], []USMUser{testUser})
+			if err != nil {
+				continue
+			}
+			requests <- request
+
+			response := &Packet{Version: Version3, MessageID: request.MessageID, Security: engine, ContextEngineID: engine.EngineID}
+			switch {
+			case request.User.Name == "":
+				response.PDU = PDU{Type: Report, RequestID: request.PDU.RequestID, Variables: []Variable{{OID: "1.3.6.1.6.3.15.1.1.4.0", Type: TypeCounter32, Value: int64(1)}}}
+			case !synced:
+				synced = true
+				engine.EngineTime = 5000
+				response.User = testUser
+				response.Security = engine
+				response.PDU = PDU{Type: Report, RequestID: request.PDU.RequestID, Variables: []Variable{{OID: OIDUsmStatsNotInTimeWindows, Type: TypeCounter32, Value: int64(1)}}}
+			default:
+				response.User = testUser
+				response.PDU = PDU{Type: GetResponse, RequestID: request.PDU.RequestID}
+				for _, requested := range request.PDU.Variables {
+					response.PDU.Variables = append(response.PDU.Variables, agentGet(variables, requested.OID))
+				}
+			}
+
+			data, err := response.Marshal()
+			if err != nil {
+				return
+			}
+			conn.WriteTo(data, from)
+		}
+	}()
+
+	return conn.LocalAddr().String(), requests
+}
+
+func receiveRequest(t *testing.T, requests <-chan *Packet) *Packet {
+	t.Helper()
+	select {
+	case request := <-requests:
+		return request
+	case <-time.After(2 * time.Second):
+		t.Fatal("no request received")
+	}
+	return nil
+}
+
+func TestClientV3DiscoveryAndResync(t *testing.T) {
+	address, requests := startV3Agent(t, ontVariables())
+
+	client, err := NewClient(ClientOptions{Address: address, Version: Version3, User: testUser, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+
+	discovery := receiveRequest(t, requests)
+	if discovery.User.Name != "" || len(discovery.Security.EngineID) != 0 || !discovery.Reportable {
+		t.Fatalf("unexpected discovery request %+v", discovery)
+	}
+
+	variables, err := client.Get(OIDGponOntSerialNumber + ".4194312960.7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(variables) != 1 || string(variables[0].Value.([]byte)) != "HWTC\x0a\xbc\xde\xf1" {
+		t.Fatalf("unexpected variables %+v", variables)
+	}
+
+	first := receiveRequest(t, requests)
+	if first.User.Name != testUser.Name || first.Security.EngineBoots != 3 || first.Security.EngineTime < 100 || first.Security.EngineTime >= 5000 {
+		t.Fatalf("unexpected first request security %+v", first.Security)
+	}
+	retry := receiveRequest(t, requests)
+	if retry.Security.EngineBoots != 3 || retry.Security.EngineTime < 5000 {
+		t.Fatalf("expected the retry to use the resynchronised engine time, got %+v", retry.Security)
+	}
+}
@@ -0,0 +1,14 @@
+// Package snmp reads ONT state from Huawei GPON OLTs over SNMP v2c and v3 and
+// receives their notifications.
+//
+// No trap definitions are built in. Every notification without a
+// TrapDefinition is delivered as an AlarmTypeEvent named after its trap OID,
+// so faults and recoveries are only told apart for the trap OIDs the caller
+// defines in TrapListenerOptions.Definitions, taken from the GPON MIB shipped
+// with the OLT firmware.
+//
+// GponIfIndex and ParseGponIfIndex assume the ifIndex layout 0xFA000000 +
+// frame<<21 + slot<<13 + port<<8. It was derived from the IF-MIB ifDescr
+// ("GPON 0/1/3") of OLTs, not from Huawei documentation; walk ifDescr
+// (1.3.6.1.2.1.2.2.1.2) to confirm it before relying on it.
+package snmp
@@ -0,0 +1,169 @@
+package snmp
+
+import (
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/wuzi/HuaweiOLTSDK/pkg/sshclient"
+)
+
+const (
+	OIDSysUpTime = "1.3.6.1.2.1.1.3.0"
+	OIDTrapOID   = "1.3.6.1.6.3.1.1.4.1.0"
+	OIDIfIndex   = "1.3.6.1.2.1.2.2.1.1"
+
+	OIDHuaweiGPON           = "1.3.6.1.4.1.2011.6.128"
+	OIDGponDeviceOntObjects = "1.3.6.1.4.1.2011.6.128.1.1.2"
+	OIDGponOntSerialNumber  = OIDGponDeviceOntObjects + ".43.1.3"
+	OIDGponOntDescription   = OIDGponDeviceOntObjects + ".43.1.9"
+	OIDGponOntRunStatus     = OIDGponDeviceOntObjects + ".46.1.15"
+	OIDGponOntDistance      = OIDGponDeviceOntObjects + ".46.1.20"
+
+	OIDGponOntOpticalTemperature = OIDGponDeviceOntObjects + ".51.1.1"
+	OIDGponOntOpticalBias        = OIDGponDeviceOntObjects + ".51.1.2"
+	OIDGponOntOpticalTxPower     = OIDGponDeviceOntObjects + ".51.1.3"
+	OIDGponOntOpticalRxPower     = OIDGponDeviceOntObjects + ".51.1.4"
+	OIDGponOntOpticalVoltage     = OIDGponDeviceOntObjects + ".51.1.5"
+	OIDGponOntOpticalOLTRxPower  = OIDGponDeviceOntObjects + ".51.1.6"
+)
+
+const gponIfIndexBase = 0xfa000000
+
+// GponIfIndex returns the ifIndex the OLT uses for a GPON port in its MIB
+// tables and traps. See the package documentation for where the layout comes
+// from.
+func GponIfIndex(frame, slot, port int) uint32 {
+	return gponIfIndexBase + uint32(frame)<<21 + uint32(slot)<<13 + uint32(port)<<8
+}
+
+func ParseGponIfIndex(ifIndex uint64) (frame, slot, port int, ok bool) {
+	if ifIndex&0xfe000000 != gponIfIndexBase || ifIndex > 0xffffffff || ifIndex&0xff != 0 {
+		return 0, 0, 0, false
+	}
+	return int(ifIndex>>21) & 0xf, int(ifIndex>>13) & 0xff, int(ifIndex>>8) & 0x1f, true
+}
+
+// TrapDefinition maps a notification OID to the alarm it reports. None are
+// built in; see the package documentation.
+type TrapDefinition struct {
+	OID      string
+	Name     string
+	AlarmID  string
+	Type     sshclient.AlarmType
+	Severity sshclient.AlarmSeverity
+}
+
+// DecodeTrap turns the variable bindings of an SNMPv2 notification into an
+// alarm. Notifications without a matching definition become events named
+// after their trap OID.
+func DecodeTrap(variables []Variable, definitions map[string]TrapDefinition) sshclient.Alarm {
+	alarm := sshclient.Alarm{Type: sshclient.AlarmTypeEvent, Parameters: map[string]string{}}
+
+	for _, variable := range variables {
+		oid := strings.TrimPrefix(variable.OID, ".")
+		switch {
+		case oid == OIDSysUpTime:
+			continue
+		case oid == OIDTrapOID:
+			trapOID := strings.TrimPrefix(variable.String(), ".")
+			alarm.Category = trapOID
+			if definition, ok := definitions[trapOID]; ok {
+				alarm.Name = definition.Name
+				alarm.AlarmID = definition.AlarmID
+				alarm.Type = definition.Type
+				alarm.Severity = definition.Severity
+			} else {
+				alarm.Name = trapOID
+			}
+			continue
+		}
+
+		alarm.Parameters[oid] = formatValue(variable)
+
+		if strings.HasPrefix(oid, OIDGponOntSerialNumber+".") {
+			if value, ok := variable.Bytes(); ok {
+				alarm.SerialNumber = strings.ToUpper(hex.EncodeToString(value))
+			}
+		} else if alarm.SerialNumber == "" && isSerialNumber(variable) {
+			value, _ := variable.Bytes()
+			alarm.SerialNumber = strings.ToUpper(hex.EncodeToString(value))
+		}
+
+		if alarm.Slot == nil && (strings.HasPrefix(oid, OIDHuaweiGPON+".") || strings.HasPrefix(oid, OIDIfIndex+".")) {
+			setLocationFromInstance(&alarm, oid)
+		}
+		if alarm.Slot == nil {
+			if value, ok := variable.Int(); ok && value > 0 {
+				setLocation(&alarm, uint64(value), nil)
+			}
+		}
+	}
+
+	return alarm
+}
+
+// setLocationFromInstance reads the ifIndex and ONT ID that index the GPON
+// ONT tables from the instance part of a variable OID.
+func setLocationFromInstance(alarm *sshclient.Alarm, oid string) {
+	parts := strings.Split(oid, ".")
+	for i := len(parts) - 1; i >= 0; i-- {
+		ifIndex, err := strconv.ParseUint(parts[i], 10, 64)
+		if err != nil {
+			return
+		}
+		if _, _, _, ok := ParseGponIfIndex(ifIndex); !ok {
+			continue
+		}
+
+		var ontID *int
+		if i+1 < len(parts) {
+			if id, err := strconv.Atoi(parts[i+1]); err == nil {
+				ontID = &id
+			}
+		}
+		setLocation(alarm, ifIndex, ontID)
+		return
+	}
+}
+
+func setLocation(alarm *sshclient.Alarm, ifIndex uint64, ontID *int) {
+	frame, slot, port, ok := ParseGponIfIndex(ifIndex)
+	if !ok {
+		return
+	}
+	alarm.Frame, alarm.Slot, alarm.Port = &frame, &slot, &port
+	if ontID != nil {
+		alarm.ONTID = ontID
+	}
+}
+
+// isSerialNumber reports whether a value looks like a GPON serial number:
+// a four-letter vendor ID followed by four binary bytes.
+func isSerialNumber(variable Variable) bool {
+	value, ok := variable.Bytes()
+	if !ok || len(value) != 8 {
+		return false
+	}
+	for _, b := range value[:4] {
+		if b < 'A' || b > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func formatValue(variable Variable) string {
+	value, ok := variable.Bytes()
+	if !ok {
+		return variable.String()
+	}
+	value = []byte(strings.TrimRight(string(value), "\x00"))
+	for _, r := range string(value) {
+		if r == unicode.ReplacementChar || (!unicode.IsPrint(r) && !unicode.IsSpace(r)) {
+			return strings.ToUpper(hex.EncodeToString(value))
+		}
+	}
+	return string(value)
+}
@@ -0,0 +1,31 @@
+package snmp
+
+import (
+	"testing"
+
+	"github.com/wuzi/HuaweiOLTSDK/pkg/sshclient"
+)
+
+func TestGponIfIndex(t *testing.T) {
+	if ifIndex := GponIfIndex(0, 1, 3); ifIndex != 4194312960 {
+		t.Fatalf("expected 4194312960 for GPON 0/1/3, got %d", ifIndex)
+	}
+
+	frame, slot, port, ok := ParseGponIfIndex(uint64(GponIfIndex(1, 17, 15)))
+	if !ok || frame != 1 || slot != 17 || port != 15 {
+		t.Fatalf("got %d/%d/%d %v", frame, slot, port, ok)
+	}
+
+	for _, ifIndex := range []uint64{7, 4194312961, 1 << 33} {
+		if _, _, _, ok := ParseGponIfIndex(ifIndex); ok {
+			t.Fatalf("expected %d to be rejected", ifIndex)
+		}
+	}
+}
+
+func TestDecodeTrapWithoutDefinition(t *testing.T) {
+	alarm := DecodeTrap(NewTrap("", testTrapOID, 0, ontVariables()...).PDU.Variables, nil)
+	if alarm.Type != sshclient.AlarmTypeEvent || alarm.Name != testTrapOID || alarm.Severity != "" {
+		t.Fatalf("unexpected alarm %+v", alarm)
+	}
+}
@@ -0,0 +1,206 @@
+package snmp
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/wuzi/HuaweiOLTSDK/pkg/sshclient"
+)
+
+// invalidReading is what the OLT reports for optical values it cannot read,
+// typically because the ONT is offline.
+const invalidReading = 2147483647
+
+var _ sshclient.ONTReader = (*Client)(nil)
+
+var generalInfoColumns = []string{OIDGponOntSerialNumber, OIDGponOntDescription, OIDGponOntRunStatus, OIDGponOntDistance}
+
+var opticalInfoColumns = []string{
+	OIDGponOntOpticalTemperature,
+	OIDGponOntOpticalBias,
+	OIDGponOntOpticalTxPower,
+	OIDGponOntOpticalRxPower,
+	OIDGponOntOpticalVoltage,
+	OIDGponOntOpticalOLTRxPower,
+}
+
+// GetGeneralInfo fills the run state, distance, SN and description of an ONT
+// from the hwGponDeviceOntObjects tables. Fields only available through the
+// CLI are left empty.
+func (c *Client) GetGeneralInfo(frame, slot, port, ontID int) (*sshclient.GeneralInfo, error) {
+	variables, err := c.Get(instances(generalInfoColumns, frame, slot, port, ontID)...)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &sshclient.GeneralInfo{FSP: fmt.Sprintf("%d/%d/%d", frame, slot, port), ID: strconv.Itoa(ontID)}
+	for i, variable := range variables {
+		if i >= len(generalInfoColumns) {
+			break
+		}
+		if isMissing(variable) {
+			return nil, sshclient.NotFoundError{}
+		}
+		setGeneralInfo(info, generalInfoColumns[i], variable)
+	}
+	return info, nil
+}
+
+func (c *Client) GetONTOpticalInfo(frame, slot, port, ontID int) (*sshclient.OpticalInfo, error) {
+	variables, err := c.Get(instances(opticalInfoColumns, frame, slot, port, ontID)...)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &sshclient.OpticalInfo{}
+	for i, variable := range variables {
+		if i >= len(opticalInfoColumns) {
+			break
+		}
+		if isMissing(variable) {
+			return nil, sshclient.NotFoundError{}
+		}
+		setOpticalInfo(info, opticalInfoColumns[i], variable)
+	}
+	return info, nil
+}
+
+// GetPortGeneralInfo walks the ONT tables for every ONT on a GPON port,
+// keyed by ONT ID.
+func (c *Client) GetPortGeneralInfo(frame, slot, port int) (map[int]*sshclient.GeneralInfo, error) {
+	fsp := fmt.Sprintf("%d/%d/%d", frame, slot, port)
+	infos := map[int]*sshclient.GeneralInfo{}
+
+	err := c.walkPort(generalInfoColumns, frame, slot, port, func(column string, ontID int, variable Variable) {
+		info, ok := infos[ontID]
+		if !ok {
+			info = &sshclient.GeneralInfo{FSP: fsp, ID: strconv.Itoa(ontID)}
+			infos[ontID] = info
+		}
+		setGeneralInfo(info, column, variable)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+// GetPortOpticalInfo walks the optical DDM table for every ONT on a GPON
+// port, keyed by ONT ID.
+func (c *Client) GetPortOpticalInfo(frame, slot, port int) (map[int]*sshclient.OpticalInfo, error) {
+	infos := map[int]*sshclient.OpticalInfo{}
+
+	err := c.walkPort(opticalInfoColumns, frame, slot, port, func(column string, ontID int, variable Variable) {
+		info, ok := infos[ontID]
+		if !ok {
+			info = &sshclient.OpticalInfo{}
+			infos[ontID] = info
+		}
+		setOpticalInfo(info, column, variable)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func (c *Client) walkPort(columns []string, frame, slot, port int, handle func(column string, ontID int, variable Variable)) error {
+	ifIndex := strconv.FormatUint(uint64(GponIfIndex(frame, slot, port)), 10)
+	for _, column := range columns {
+		root := column + "." + ifIndex
+		err := c.Walk(root, func(variable Variable) error {
+			ontID, err := strconv.Atoi(strings.TrimPrefix(variable.OID, root+"."))
+			if err != nil {
+				return nil
+			}
+			handle(column, ontID, variable)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func instances(columns []string, frame, slot, port, ontID int) []string {
+	oids := make([]string, len(columns))
+	for i, column := range columns {
+		oids[i] = fmt.Sprintf("%s.%d.%d", column, GponIfIndex(frame, slot, port), ontID)
+	}
+	return oids
+}
+
+func isMissing(variable Variable) bool {
+	return variable.Type == TypeNoSuchObject || variable.Type == TypeNoSuchInstance || variable.Type == TypeEndOfMibView
+}
+
+// setGeneralInfo formats values the way the CLI prints them, so GeneralInfo
+// and its Metrics behave the same for both backends.
+func setGeneralInfo(info *sshclient.GeneralInfo, column string, variable Variable) {
+	switch column {
+	case OIDGponOntSerialNumber:
+		info.SN = formatSerialNumber(variable)
+	case OIDGponOntDescription:
+		info.Description = formatValue(variable)
+	case OIDGponOntRunStatus:
+		value, _ := variable.Int()
+		switch value {
+		case 1:
+			info.RunState = "online"
+		case 2:
+			info.RunState = "offline"
+		default:
+			info.RunState = "-"
+		}
+	case OIDGponOntDistance:
+		value, ok := variable.Int()
+		if !ok || value < 0 {
+			info.Distance = "-"
+		} else {
+			info.Distance = strconv.FormatInt(value, 10)
+		}
+	}
+}
+
+func setOpticalInfo(info *sshclient.OpticalInfo, column string, variable Variable) {
+	value, ok := variable.Int()
+	if !ok {
+		value = invalidReading
+	}
+
+	switch column {
+	case OIDGponOntOpticalTemperature:
+		info.Temperature = formatReading(value, "%.0f", 1, 0)
+	case OIDGponOntOpticalBias:
+		info.LaserBiasCurrent = formatReading(value, "%.0f", 1, 0)
+	case OIDGponOntOpticalTxPower:
+		info.TxOpticalPower = formatReading(value, "%.2f", 100, 0)
+	case OIDGponOntOpticalRxPower:
+		info.RxOpticalPower = formatReading(value, "%.2f", 100, 0)
+	case OIDGponOntOpticalVoltage:
+		info.Voltage = formatReading(value, "%.3f", 100, 0)
+	case OIDGponOntOpticalOLTRxPower:
+		info.OLTRxONTOpticalPower = formatReading(value, "%.2f", 100, 10000)
+	}
+}
+
+func formatReading(value int64, format string, scale, offset float64) string {
+	if value == invalidReading {
+		return "-"
+	}
+	return fmt.Sprintf(format, (float64(value)-offset)/scale)
+}
+
+// formatSerialNumber renders an SN as "485754431234ABCD (HWTC-1234ABCD)",
+// as in the output of display ont info.
+func formatSerialNumber(variable Variable) string {
+	value, ok := variable.Bytes()
+	if !ok || len(value) != 8 {
+		return formatValue(variable)
+	}
+	serial := strings.ToUpper(hex.EncodeToString(value))
+	return fmt.Sprintf("%s (%s-%s)", serial, string(value[:4]), serial[8:])
+}
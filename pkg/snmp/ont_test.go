@@ -0,0 +1,219 @@
+package snmp
+
+import (
+	"encoding/hex"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wuzi/HuaweiOLTSDK/pkg/sshclient"
+)
+
+// readWalk loads a testdata file in the "snmpwalk -On" format.
+func readWalk(t *testing.T, name string) []Variable {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	variables := make([]Variable, 0)
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		oid, value, _ := strings.Cut(line, " = ")
+		kind, value, _ := strings.Cut(value, ": ")
+		variable := Variable{OID: strings.TrimPrefix(oid, ".")}
+		switch kind {
+		case "INTEGER":
+			number, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				t.Fatal(err)
+			}
+			variable.Type, variable.Value = TypeInteger, number
+		case "STRING":
+			variable.Type, variable.Value = TypeOctetString, []byte(strings.Trim(value, `"`))
+		case "Hex-STRING":
+			bytes, err := hex.DecodeString(strings.ReplaceAll(value, " ", ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			variable.Type, variable.Value = TypeOctetString, bytes
+		default:
+			t.Fatalf("unsupported type %q", kind)
+		}
+		variables = append(variables, variable)
+	}
+
+	sort.Slice(variables, func(i, j int) bool { return compareOID(variables[i].OID, variables[j].OID) < 0 })
+	return variables
+}
+
+// startAgent answers v2c Get and GetBulk requests from variables on a
+// loopback port and returns its address.
+func startAgent(t *testing.T, community string, variables []Variable) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buffer := make([]byte, maxMessageSize)
+		for {
+			n, from, err := conn.ReadFrom(buffer)
+			if err != nil {
+				return
+			}
+			request, err := Unmarshal(buffer[:n], nil)
+			if err != nil || request.Community != community {
+				continue
+			}
+
+			response := &Packet{Version: Version2c, Community: community, PDU: PDU{Type: GetResponse, RequestID: request.PDU.RequestID}}
+			for _, requested := range request.PDU.Variables {
+				if request.PDU.Type == GetBulkRequest {
+					response.PDU.Variables = append(response.PDU.Variables, agentNext(variables, requested.OID, request.PDU.ErrorIndex)...)
+				} else {
+					response.PDU.Variables = append(response.PDU.Variables, agentGet(variables, requested.OID))
+				}
+			}
+
+			data, err := response.Marshal()
+			if err != nil {
+				return
+			}
+			conn.WriteTo(data, from)
+		}
+	}()
+
+	return conn.LocalAddr().String()
+}
+
+func agentGet(variables []Variable, oid string) Variable {
+	for _, variable := range variables {
+		if variable.OID == oid {
+			return variable
+		}
+	}
+	return Variable{OID: oid, Type: TypeNoSuchInstance}
+}
+
+func agentNext(variables []Variable, oid string, count int) []Variable {
+	results := make([]Variable, 0, count)
+	for _, variable := range variables {
+		if len(results) == count {
+			break
+		}
+		if compareOID(variable.OID, oid) > 0 {
+			results = append(results, variable)
+		}
+	}
+	if len(results) < count {
+		results = append(results, Variable{OID: oid, Type: TypeEndOfMibView})
+	}
+	return results
+}
+
+func newTestClient(t *testing.T, address string) *Client {
+	t.Helper()
+	client, err := NewClient(ClientOptions{Address: address, Version: Version2c, Community: "public", Timeout: time.Second, MaxRepetitions: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestGetONTOpticalInfo(t *testing.T) {
+	client := newTestClient(t, startAgent(t, "public", readWalk(t, "ont_port.walk")))
+
+	info, err := client.GetONTOpticalInfo(0, 1, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := sshclient.OpticalInfo{
+		RxOpticalPower:       "-21.55",
+		TxOpticalPower:       "2.35",
+		LaserBiasCurrent:     "11",
+		Temperature:          "48",
+		Voltage:              "3.280",
+		OLTRxONTOpticalPower: "-24.56",
+	}
+	if *info != expected {
+		t.Fatalf("got %+v, want %+v", *info, expected)
+	}
+}
+
+func TestGetONTOpticalInfoNotFound(t *testing.T) {
+	client := newTestClient(t, startAgent(t, "public", readWalk(t, "ont_port.walk")))
+
+	_, err := client.GetONTOpticalInfo(0, 1, 3, 9)
+	if _, ok := err.(sshclient.NotFoundError); !ok {
+		t.Fatalf("expected NotFoundError, got %v", err)
+	}
+}
+
+func TestGetPortOpticalInfo(t *testing.T) {
+	client := newTestClient(t, startAgent(t, "public", readWalk(t, "ont_port.walk")))
+
+	infos, err := client.GetPortOpticalInfo(0, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 ONTs, got %d", len(infos))
+	}
+	if infos[0].Voltage != "3.280" || infos[1].Voltage != "-" || infos[1].RxOpticalPower != "-" {
+		t.Fatalf("unexpected optical info %+v %+v", infos[0], infos[1])
+	}
+}
+
+func TestGetPortGeneralInfo(t *testing.T) {
+	client := newTestClient(t, startAgent(t, "public", readWalk(t, "ont_port.walk")))
+
+	infos, err := client.GetPortGeneralInfo(0, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := sshclient.GeneralInfo{
+		FSP:         "0/1/3",
+		ID:          "1",
+		RunState:    "offline",
+		Distance:    "-",
+		SN:          "485754430ABCDEF2 (HWTC-0ABCDEF2)",
+		Description: "customer 2",
+	}
+	if len(infos) != 2 || *infos[1] != expected {
+		t.Fatalf("got %+v, want %+v", infos[1], expected)
+	}
+}
+
+func TestGetGeneralInfo(t *testing.T) {
+	client := newTestClient(t, startAgent(t, "public", readWalk(t, "ont_port.walk")))
+
+	info, err := client.GetGeneralInfo(0, 1, 3, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := sshclient.GeneralInfo{
+		FSP:         "0/1/3",
+		ID:          "0",
+		RunState:    "online",
+		Distance:    "1520",
+		SN:          "485754430ABCDEF1 (HWTC-0ABCDEF1)",
+		Description: "customer 1",
+	}
+	if *info != expected {
+		t.Fatalf("got %+v, want %+v", *info, expected)
+	}
+
+	_, err = client.GetGeneralInfo(0, 1, 3, 9)
+	if _, ok := err.(sshclient.NotFoundError); !ok {
+		t.Fatalf("expected NotFoundError, got %v", err)
+	}
+}
@@ -0,0 +1,374 @@
+package snmp
+
+import (
+	"crypto/hmac"
+	"fmt"
+)
+
+type Version int
+
+const (
+	Version1  Version = 0
+	Version2c Version = 1
+	Version3  Version = 3
+)
+
+type PDUType byte
+
+const (
+	GetRequest     PDUType = 0xa0
+	GetNextRequest PDUType = 0xa1
+	GetResponse    PDUType = 0xa2
+	SetRequest     PDUType = 0xa3
+	TrapV1         PDUType = 0xa4
+	GetBulkRequest PDUType = 0xa5
+	InformRequest  PDUType = 0xa6
+	SNMPv2Trap     PDUType = 0xa7
+	Report         PDUType = 0xa8
+)
+
+const (
+	securityModelUSM = 3
+	maxMessageSize   = 65507
+)
+
+// PDU is an SNMPv2 protocol data unit. For GetBulkRequest, ErrorStatus and
+// ErrorIndex carry non-repeaters and max-repetitions.
+type PDU struct {
+	Type        PDUType
+	RequestID   int32
+	ErrorStatus int
+	ErrorIndex  int
+	Variables   []Variable
+}
+
+type SecurityParameters struct {
+	EngineID    []byte
+	EngineBoots int32
+	EngineTime  int32
+}
+
+// Packet is an SNMP message. Community is used by v1 and v2c; User,
+// Security and the context fields are used by v3.
+type Packet struct {
+	Version         Version
+	Community       string
+	MessageID       int32
+	Reportable      bool
+	User            USMUser
+	Security        SecurityParameters
+	ContextEngineID []byte
+	ContextName     string
+	PDU             PDU
+}
+
+func (p *Packet) Marshal() ([]byte, error) {
+	pdu, err := p.PDU.marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.Version {
+	case Version1, Version2c:
+		return encodeSequence(tagSequence, encodeInteger(int64(p.Version)), encodeOctetString([]byte(p.Community)), pdu), nil
+	case Version3:
+		return p.marshalV3(pdu)
+	default:
+		return nil, fmt.Errorf("unsupported SNMP version %d", p.Version)
+	}
+}
+
+func (p *Packet) marshalV3(pdu []byte) ([]byte, error) {
+	// An anonymous noAuthNoPriv user is only used for engine discovery.
+	if p.User.Name != "" || p.User.flags() != 0 {
+		if err := p.User.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	flags := p.User.flags()
+	if p.Reportable {
+		flags |= flagReportable
+	}
+
+	data := encodeSequence(tagSequence, encodeOctetString(p.ContextEngineID), encodeOctetString([]byte(p.ContextName)), pdu)
+
+	var privParameters []byte
+	if flags&flagPriv != 0 {
+		ciphertext, salt, err := p.User.encrypt(data, p.Security.EngineID, p.Security.EngineBoots, p.Security.EngineTime)
+		if err != nil {
+			return nil, err
+		}
+		data = encodeOctetString(ciphertext)
+		privParameters = salt
+	}
+
+	var authParameters []byte
+	if flags&flagAuth != 0 {
+		authParameters = make([]byte, authParametersSize)
+	}
+
+	security := encodeSequence(tagSequence,
+		encodeOctetString(p.Security.EngineID),
+		encodeInteger(int64(p.Security.EngineBoots)),
+		encodeInteger(int64(p.Security.EngineTime)),
+		encodeOctetString([]byte(p.User.Name)),
+		encodeOctetString(authParameters),
+		encodeOctetString(privParameters),
+	)
+	header := encodeSequence(tagSequence,
+		encodeInteger(int64(p.MessageID)),
+		encodeInteger(maxMessageSize),
+		encodeOctetString([]byte{flags}),
+		encodeInteger(securityModelUSM),
+	)
+	message := encodeSequence(tagSequence, encodeInteger(int64(Version3)), header, encodeOctetString(security), data)
+
+	if flags&flagAuth != 0 {
+		offset, err := authParametersOffset(message)
+		if err != nil {
+			return nil, err
+		}
+		copy(message[offset:], p.User.digest(message, p.Security.EngineID))
+	}
+
+	return message, nil
+}
+
+func (pdu PDU) marshal() ([]byte, error) {
+	if pdu.Type == TrapV1 {
+		return nil, fmt.Errorf("SNMPv1 trap PDUs are not supported")
+	}
+
+	variables := make([][]byte, 0, len(pdu.Variables))
+	for _, variable := range pdu.Variables {
+		encoded, err := encodeVariable(variable)
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, encoded)
+	}
+
+	return encodeSequence(byte(pdu.Type),
+		encodeInteger(int64(pdu.RequestID)),
+		encodeInteger(int64(pdu.ErrorStatus)),
+		encodeInteger(int64(pdu.ErrorIndex)),
+		encodeSequence(tagSequence, variables...),
+	), nil
+}
+
+// Unmarshal decodes an SNMP message. SNMPv3 messages are authenticated and
+// decrypted with the matching entry of users.
+func Unmarshal(data []byte, users []USMUser) (*Packet, error) {
+	message, err := newDecoder(data).enter(tagSequence)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := message.integer()
+	if err != nil {
+		return nil, err
+	}
+
+	packet := &Packet{Version: Version(version)}
+	switch packet.Version {
+	case Version1, Version2c:
+		community, err := message.octetString()
+		if err != nil {
+			return nil, err
+		}
+		packet.Community = string(community)
+		packet.PDU, err = unmarshalPDU(message)
+		return packet, err
+	case Version3:
+		return packet, packet.unmarshalV3(data, message, users)
+	default:
+		return nil, fmt.Errorf("unsupported SNMP version %d", version)
+	}
+}
+
+func (p *Packet) unmarshalV3(data []byte, message *decoder, users []USMUser) error {
+	header, err := message.enter(tagSequence)
+	if err != nil {
+		return err
+	}
+	messageID, err := header.integer()
+	if err != nil {
+		return err
+	}
+	p.MessageID = int32(messageID)
+	if _, err := header.integer(); err != nil {
+		return err
+	}
+	flags, err := header.octetString()
+	if err != nil {
+		return err
+	}
+	if len(flags) != 1 {
+		return fmt.Errorf("invalid SNMPv3 message flags")
+	}
+	model, err := header.integer()
+	if err != nil {
+		return err
+	}
+	if model != securityModelUSM {
+		return fmt.Errorf("unsupported SNMPv3 security model %d", model)
+	}
+	p.Reportable = flags[0]&flagReportable != 0
+
+	securityOctets, err := message.enter(byte(TypeOctetString))
+	if err != nil {
+		return err
+	}
+	security, err := securityOctets.enter(tagSequence)
+	if err != nil {
+		return err
+	}
+	if p.Security.EngineID, err = security.octetString(); err != nil {
+		return err
+	}
+	boots, err := security.integer()
+	if err != nil {
+		return err
+	}
+	time, err := security.integer()
+	if err != nil {
+		return err
+	}
+	p.Security.EngineBoots, p.Security.EngineTime = int32(boots), int32(time)
+	userName, err := security.octetString()
+	if err != nil {
+		return err
+	}
+	authParameters, authOffset, err := security.expect(byte(TypeOctetString))
+	if err != nil {
+		return err
+	}
+	privParameters, err := security.octetString()
+	if err != nil {
+		return err
+	}
+
+	p.User = USMUser{Name: string(userName)}
+	if flags[0]&(flagAuth|flagPriv) != 0 {
+		user, ok := findUser(users, p.User.Name)
+		if !ok {
+			return fmt.Errorf("unknown SNMPv3 user %q", p.User.Name)
+		}
+		if user.flags() != flags[0]&(flagAuth|flagPriv) {
+			return fmt.Errorf("unsupported security level for SNMPv3 user %q", p.User.Name)
+		}
+		p.User = user
+
+		if len(authParameters) != authParametersSize {
+			return fmt.Errorf("invalid SNMPv3 authentication parameters")
+		}
+		signed := append([]byte(nil), data...)
+		copy(signed[authOffset:], make([]byte, authParametersSize))
+		if !hmac.Equal(authParameters, user.digest(signed, p.Security.EngineID)) {
+			return fmt.Errorf("SNMPv3 authentication failed for user %q", p.User.Name)
+		}
+	}
+
+	scoped := message
+	if flags[0]&flagPriv != 0 {
+		ciphertext, err := message.octetString()
+		if err != nil {
+			return err
+		}
+		plaintext, err := p.User.decrypt(ciphertext, p.Security.EngineID, p.Security.EngineBoots, p.Security.EngineTime, privParameters)
+		if err != nil {
+			return err
+		}
+		scoped = newDecoder(plaintext)
+	}
+
+	scopedPDU, err := scoped.enter(tagSequence)
+	if err != nil {
+		return fmt.Errorf("could not decode scoped PDU: %w", err)
+	}
+	if p.ContextEngineID, err = scopedPDU.octetString(); err != nil {
+		return err
+	}
+	contextName, err := scopedPDU.octetString()
+	if err != nil {
+		return err
+	}
+	p.ContextName = string(contextName)
+	p.PDU, err = unmarshalPDU(scopedPDU)
+	return err
+}
+
+func unmarshalPDU(d *decoder) (PDU, error) {
+	tag, value, offset, err := d.read()
+	if err != nil {
+		return PDU{}, err
+	}
+	if tag == byte(TrapV1) {
+		return PDU{}, fmt.Errorf("SNMPv1 trap PDUs are not supported")
+	}
+	if tag < byte(GetRequest) || tag > byte(Report) {
+		return PDU{}, fmt.Errorf("unexpected PDU type 0x%02x", tag)
+	}
+
+	body := &decoder{data: d.data, pos: offset, end: offset + len(value)}
+	pdu := PDU{Type: PDUType(tag)}
+	fields := make([]int64, 3)
+	for i := range fields {
+		if fields[i], err = body.integer(); err != nil {
+			return PDU{}, err
+		}
+	}
+	pdu.RequestID, pdu.ErrorStatus, pdu.ErrorIndex = int32(fields[0]), int(fields[1]), int(fields[2])
+
+	variables, err := body.enter(tagSequence)
+	if err != nil {
+		return PDU{}, err
+	}
+	for variables.more() {
+		variable, err := decodeVariable(variables)
+		if err != nil {
+			return PDU{}, err
+		}
+		pdu.Variables = append(pdu.Variables, variable)
+	}
+
+	return pdu, nil
+}
+
+func authParametersOffset(message []byte) (int, error) {
+	d, err := newDecoder(message).enter(tagSequence)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := d.integer(); err != nil {
+		return 0, err
+	}
+	if _, _, err := d.expect(tagSequence); err != nil {
+		return 0, err
+	}
+	octets, err := d.enter(byte(TypeOctetString))
+	if err != nil {
+		return 0, err
+	}
+	security, err := octets.enter(tagSequence)
+	if err != nil {
+		return 0, err
+	}
+	for i := 0; i < 4; i++ {
+		if _, _, _, err := security.read(); err != nil {
+			return 0, err
+		}
+	}
+	_, offset, err := security.expect(byte(TypeOctetString))
+	return offset, err
+}
+
+func findUser(users []USMUser, name string) (USMUser, bool) {
+	for _, user := range users {
+		if user.Name == name {
+			return user, true
+		}
+	}
+	return USMUser{}, false
+}
@@ -0,0 +1,273 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/wuzi/HuaweiOLTSDK/pkg/sshclient"
+)
+
+type TrapListenerOptions struct {
+	// Community restricts v2c notifications; empty accepts any community.
+	Community string
+	// Users authenticates v3 notifications. Unauthenticated v3 messages are
+	// only accepted for users listed here without an auth protocol.
+	Users []USMUser
+	// Definitions names notifications; the others are delivered as events
+	// named after their trap OID.
+	Definitions []TrapDefinition
+	BufferSize  int
+	// ErrorHandler is called for datagrams that cannot be decoded or fail
+	// authentication. They are dropped either way.
+	ErrorHandler func(from net.Addr, err error)
+}
+
+// TrapListener receives SNMPv2c and SNMPv3 notifications from the OLT and
+// delivers them as events, the same type AlarmMonitor produces.
+type TrapListener struct {
+	options     TrapListenerOptions
+	definitions map[string]TrapDefinition
+	events      chan sshclient.Event
+	done        chan struct{}
+	once        sync.Once
+	mu          sync.Mutex
+	conns       []net.PacketConn
+	clockMu     sync.Mutex
+	clocks      map[string]engineClock
+}
+
+// engineClock is the notion of a sending engine's boots and time kept for
+// the timeliness check of RFC 3414 section 3.2 step 7b.
+type engineClock struct {
+	boots      int32
+	time       int32
+	receivedAt time.Time
+}
+
+// timeWindow is the number of seconds a message may lag behind the engine
+// time of its sender.
+const timeWindow = 150
+
+func NewTrapListener(options TrapListenerOptions) (*TrapListener, error) {
+	for _, user := range options.Users {
+		if err := user.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if options.BufferSize <= 0 {
+		options.BufferSize = 64
+	}
+
+	definitions := make(map[string]TrapDefinition, len(options.Definitions))
+	for _, definition := range options.Definitions {
+		definitions[definition.OID] = definition
+	}
+
+	return &TrapListener{
+		options:     options,
+		definitions: definitions,
+		events:      make(chan sshclient.Event, options.BufferSize),
+		done:        make(chan struct{}),
+		clocks:      map[string]engineClock{},
+	}, nil
+}
+
+// Events is closed when Close is called.
+func (l *TrapListener) Events() <-chan sshclient.Event {
+	return l.events
+}
+
+func (l *TrapListener) ListenAndServe(address string) error {
+	conn, err := net.ListenPacket("udp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %v", address, err)
+	}
+	return l.Serve(conn)
+}
+
+// Serve reads notifications from conn until Close is called. It takes
+// ownership of conn.
+func (l *TrapListener) Serve(conn net.PacketConn) error {
+	l.mu.Lock()
+	select {
+	case <-l.done:
+		l.mu.Unlock()
+		conn.Close()
+		return net.ErrClosed
+	default:
+	}
+	l.conns = append(l.conns, conn)
+	l.mu.Unlock()
+
+	buffer := make([]byte, maxMessageSize)
+	for {
+		n, from, err := conn.ReadFrom(buffer)
+		if err != nil {
+			select {
+			case <-l.done:
+				return nil
+			default:
+			}
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				continue
+			}
+			return err
+		}
+
+		if err := l.handle(conn, from, buffer[:n]); err != nil && l.options.ErrorHandler != nil {
+			l.options.ErrorHandler(from, err)
+		}
+	}
+}
+
+func (l *TrapListener) Close() error {
+	var err error
+	l.once.Do(func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		close(l.done)
+		for _, conn := range l.conns {
+			if closeErr := conn.Close(); closeErr != nil && err == nil {
+				err = closeErr
+			}
+		}
+		close(l.events)
+	})
+	return err
+}
+
+func (l *TrapListener) handle(conn net.PacketConn, from net.Addr, data []byte) error {
+	receivedAt := time.Now()
+
+	packet, err := Unmarshal(data, l.options.Users)
+	if err != nil {
+		return err
+	}
+
+	switch packet.Version {
+	case Version2c:
+		if l.options.Community != "" && packet.Community != l.options.Community {
+			return fmt.Errorf("unexpected community %q", packet.Community)
+		}
+	case Version3:
+		user, ok := findUser(l.options.Users, packet.User.Name)
+		if !ok || user.flags() != packet.User.flags() {
+			return fmt.Errorf("unauthorized SNMPv3 user %q", packet.User.Name)
+		}
+		if user.flags()&flagAuth != 0 {
+			if err := l.checkTimeliness(packet.Security, receivedAt); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unsupported SNMP version %d", packet.Version)
+	}
+
+	switch packet.PDU.Type {
+	case SNMPv2Trap:
+	case InformRequest:
+		if packet.Version != Version2c {
+			return fmt.Errorf("SNMPv3 informs are not supported")
+		}
+		response := &Packet{
+			Version:   packet.Version,
+			Community: packet.Community,
+			PDU:       PDU{Type: GetResponse, RequestID: packet.PDU.RequestID, Variables: packet.PDU.Variables},
+		}
+		reply, err := response.Marshal()
+		if err != nil {
+			return err
+		}
+		if _, err := conn.WriteTo(reply, from); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unexpected PDU type 0x%02x", byte(packet.PDU.Type))
+	}
+
+	alarm := DecodeTrap(packet.PDU.Variables, l.definitions)
+	if alarm.Type == sshclient.AlarmTypeRecovery {
+		alarm.ClearedAt = &receivedAt
+	} else {
+		alarm.RaisedAt = &receivedAt
+	}
+
+	event := sshclient.Event{Source: sshclient.EventSourceSNMP, ReceivedAt: receivedAt, Alarm: alarm, Raw: fmt.Sprintf("%x", data)}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-l.done:
+	case l.events <- event:
+	default:
+		return fmt.Errorf("event buffer full, dropping notification %s", alarm.Name)
+	}
+	return nil
+}
+
+// checkTimeliness rejects authenticated notifications outside the time window
+// of their engine. The first notification of an engine sets its clock, and a
+// replay within the window is not detected.
+func (l *TrapListener) checkTimeliness(security SecurityParameters, receivedAt time.Time) error {
+	if security.EngineBoots == math.MaxInt32 {
+		return fmt.Errorf("SNMPv3 engine boots at maximum")
+	}
+
+	l.clockMu.Lock()
+	defer l.clockMu.Unlock()
+
+	engineID := string(security.EngineID)
+	clock, known := l.clocks[engineID]
+	if known {
+		estimated := int64(clock.time) + int64(receivedAt.Sub(clock.receivedAt)/time.Second)
+		if security.EngineBoots < clock.boots ||
+			(security.EngineBoots == clock.boots && int64(security.EngineTime) < estimated-timeWindow) {
+			return fmt.Errorf("SNMPv3 notification outside the time window")
+		}
+	}
+
+	if !known || security.EngineBoots > clock.boots || security.EngineTime > clock.time {
+		l.clocks[engineID] = engineClock{boots: security.EngineBoots, time: security.EngineTime, receivedAt: receivedAt}
+	}
+	return nil
+}
+
+// NewTrap builds an SNMPv2c notification, for example to exercise a
+// TrapListener from a local sender.
+func NewTrap(community, trapOID string, uptime time.Duration, variables ...Variable) *Packet {
+	return &Packet{
+		Version:   Version2c,
+		Community: community,
+		PDU: PDU{
+			Type: SNMPv2Trap,
+			Variables: append([]Variable{
+				{OID: OIDSysUpTime, Type: TypeTimeTicks, Value: uint64(uptime / (10 * time.Millisecond))},
+				{OID: OIDTrapOID, Type: TypeObjectID, Value: trapOID},
+			}, variables...),
+		},
+	}
+}
+
+// SendTrap marshals packet and sends it to address over UDP.
+func SendTrap(address string, packet *Packet) error {
+	data, err := packet.Marshal()
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %v", address, err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write(data)
+	return err
+}
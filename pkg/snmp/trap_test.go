@@ -0,0 +1,151 @@
+package snmp
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wuzi/HuaweiOLTSDK/pkg/sshclient"
+)
+
+const testTrapOID = "1.3.6.1.4.1.2011.6.128.1.1.0.99"
+
+var testUser = USMUser{Name: "noc", AuthProtocol: AuthSHA, AuthPassphrase: "authpassword", PrivProtocol: PrivAES, PrivPassphrase: "privpassword"}
+
+// startListener serves a TrapListener on a loopback port. Errors reported
+// for dropped datagrams are sent on the returned channel.
+func startListener(t *testing.T, options TrapListenerOptions) (*TrapListener, string, <-chan error) {
+	t.Helper()
+	errs := make(chan error, 8)
+	options.ErrorHandler = func(from net.Addr, err error) { errs <- err }
+
+	listener, err := NewTrapListener(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go listener.Serve(conn)
+	t.Cleanup(func() { listener.Close() })
+
+	return listener, conn.LocalAddr().String(), errs
+}
+
+func receiveEvent(t *testing.T, listener *TrapListener, errs <-chan error) sshclient.Event {
+	t.Helper()
+	select {
+	case event := <-listener.Events():
+		return event
+	case err := <-errs:
+		t.Fatalf("notification dropped: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("no notification received")
+	}
+	return sshclient.Event{}
+}
+
+func receiveError(t *testing.T, listener *TrapListener, errs <-chan error) error {
+	t.Helper()
+	select {
+	case event := <-listener.Events():
+		t.Fatalf("expected the notification to be dropped, got %+v", event)
+	case err := <-errs:
+		return err
+	case <-time.After(2 * time.Second):
+		t.Fatal("no error reported")
+	}
+	return nil
+}
+
+func ontVariables() []Variable {
+	return []Variable{
+		{OID: OIDGponOntSerialNumber + ".4194312960.7", Type: TypeOctetString, Value: []byte{'H', 'W', 'T', 'C', 0x0a, 0xbc, 0xde, 0xf1}},
+	}
+}
+
+func TestTrapListenerV2c(t *testing.T) {
+	listener, address, errs := startListener(t, TrapListenerOptions{
+		Community:   "traps",
+		Definitions: []TrapDefinition{{OID: testTrapOID, Name: "ONT LOS", AlarmID: "0x2e112001", Type: sshclient.AlarmTypeFault, Severity: sshclient.AlarmSeverityMajor}},
+	})
+
+	if err := SendTrap(address, NewTrap("traps", testTrapOID, time.Minute, ontVariables()...)); err != nil {
+		t.Fatal(err)
+	}
+
+	alarm := receiveEvent(t, listener, errs).Alarm
+	if alarm.Name != "ONT LOS" || alarm.Type != sshclient.AlarmTypeFault || alarm.SerialNumber != "485754430ABCDEF1" {
+		t.Fatalf("unexpected alarm %+v", alarm)
+	}
+	if *alarm.Frame != 0 || *alarm.Slot != 1 || *alarm.Port != 3 || *alarm.ONTID != 7 {
+		t.Fatalf("unexpected location %d/%d/%d %d", *alarm.Frame, *alarm.Slot, *alarm.Port, *alarm.ONTID)
+	}
+
+	if err := SendTrap(address, NewTrap("public", testTrapOID, time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiveError(t, listener, errs); !strings.Contains(err.Error(), "community") {
+		t.Fatalf("expected a community error, got %v", err)
+	}
+}
+
+func newV3Trap(user USMUser, boots, engineTime int32) *Packet {
+	trap := NewTrap("", testTrapOID, time.Minute, ontVariables()...)
+	return &Packet{
+		Version:   Version3,
+		MessageID: 1,
+		User:      user,
+		Security:  SecurityParameters{EngineID: []byte{0x80, 0x00, 0x07, 0xdb, 0x03, 0x01}, EngineBoots: boots, EngineTime: engineTime},
+		PDU:       trap.PDU,
+	}
+}
+
+func TestTrapListenerV3AuthPriv(t *testing.T) {
+	listener, address, errs := startListener(t, TrapListenerOptions{Users: []USMUser{testUser}})
+
+	if err := SendTrap(address, newV3Trap(testUser, 5, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	alarm := receiveEvent(t, listener, errs).Alarm
+	if alarm.Name != testTrapOID || alarm.Type != sshclient.AlarmTypeEvent || alarm.SerialNumber != "485754430ABCDEF1" {
+		t.Fatalf("unexpected alarm %+v", alarm)
+	}
+
+	wrongKey := testUser
+	wrongKey.AuthPassphrase = "otherpassword"
+	if err := SendTrap(address, newV3Trap(wrongKey, 5, 1001)); err != nil {
+		t.Fatal(err)
+	}
+	if err := receiveError(t, listener, errs); !strings.Contains(err.Error(), "authentication failed") {
+		t.Fatalf("expected an authentication error, got %v", err)
+	}
+}
+
+func TestTrapListenerV3TimeWindow(t *testing.T) {
+	listener, address, errs := startListener(t, TrapListenerOptions{Users: []USMUser{testUser}})
+
+	if err := SendTrap(address, newV3Trap(testUser, 5, 1000)); err != nil {
+		t.Fatal(err)
+	}
+	receiveEvent(t, listener, errs)
+
+	if err := SendTrap(address, newV3Trap(testUser, 5, 900)); err != nil {
+		t.Fatal(err)
+	}
+	receiveEvent(t, listener, errs)
+
+	for _, trap := range []*Packet{newV3Trap(testUser, 5, 800), newV3Trap(testUser, 4, 2000), newV3Trap(testUser, 2147483647, 0)} {
+		if err := SendTrap(address, trap); err != nil {
+			t.Fatal(err)
+		}
+		receiveError(t, listener, errs)
+	}
+
+	if err := SendTrap(address, newV3Trap(testUser, 6, 10)); err != nil {
+		t.Fatal(err)
+	}
+	receiveEvent(t, listener, errs)
+}
@@ -0,0 +1,242 @@
+package snmp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/des"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"sync"
+	"sync/atomic"
+)
+
+type AuthProtocol string
+
+const (
+	AuthNone AuthProtocol = ""
+	AuthMD5  AuthProtocol = "MD5"
+	AuthSHA  AuthProtocol = "SHA"
+)
+
+type PrivProtocol string
+
+const (
+	PrivNone PrivProtocol = ""
+	PrivDES  PrivProtocol = "DES"
+	PrivAES  PrivProtocol = "AES"
+)
+
+const (
+	flagAuth       = 0x01
+	flagPriv       = 0x02
+	flagReportable = 0x04
+
+	authParametersSize = 12
+	minPassphraseSize  = 8
+)
+
+// USMUser is an SNMPv3 user-based security model principal.
+type USMUser struct {
+	Name           string
+	AuthProtocol   AuthProtocol
+	AuthPassphrase string
+	PrivProtocol   PrivProtocol
+	PrivPassphrase string
+}
+
+func (u USMUser) Validate() error {
+	if u.Name == "" {
+		return fmt.Errorf("Invalid USM user: name is required")
+	}
+
+	switch u.AuthProtocol {
+	case AuthNone:
+		if u.PrivProtocol != PrivNone {
+			return fmt.Errorf("Invalid USM user %s: privacy requires authentication", u.Name)
+		}
+	case AuthMD5, AuthSHA:
+		if len(u.AuthPassphrase) < minPassphraseSize {
+			return fmt.Errorf("Invalid USM user %s: auth passphrase must have at least %d characters", u.Name, minPassphraseSize)
+		}
+	default:
+		return fmt.Errorf("Invalid USM user %s: unsupported auth protocol %s", u.Name, u.AuthProtocol)
+	}
+
+	switch u.PrivProtocol {
+	case PrivNone:
+	case PrivDES, PrivAES:
+		if len(u.PrivPassphrase) < minPassphraseSize {
+			return fmt.Errorf("Invalid USM user %s: priv passphrase must have at least %d characters", u.Name, minPassphraseSize)
+		}
+	default:
+		return fmt.Errorf("Invalid USM user %s: unsupported priv protocol %s", u.Name, u.PrivProtocol)
+	}
+
+	return nil
+}
+
+func (u USMUser) flags() byte {
+	var flags byte
+	if u.AuthProtocol != AuthNone {
+		flags |= flagAuth
+	}
+	if u.PrivProtocol != PrivNone {
+		flags |= flagPriv
+	}
+	return flags
+}
+
+func (u USMUser) hash() func() hash.Hash {
+	if u.AuthProtocol == AuthMD5 {
+		return md5.New
+	}
+	return sha1.New
+}
+
+type localizedKeys struct {
+	auth []byte
+	priv []byte
+}
+
+var keyCache sync.Map
+
+// keys localizes the user's passphrases to an engine ID as described in
+// RFC 3414 appendix A.2. Results are cached because each derivation hashes
+// one megabyte of data.
+func (u USMUser) keys(engineID []byte) localizedKeys {
+	cacheKey := fmt.Sprintf("%s\x00%s\x00%s\x00%s\x00%s\x00%x", u.Name, u.AuthProtocol, u.AuthPassphrase, u.PrivProtocol, u.PrivPassphrase, engineID)
+	if cached, ok := keyCache.Load(cacheKey); ok {
+		return cached.(localizedKeys)
+	}
+
+	var keys localizedKeys
+	if u.AuthProtocol != AuthNone {
+		keys.auth = localizeKey(u.hash(), u.AuthPassphrase, engineID)
+	}
+	if u.PrivProtocol != PrivNone {
+		keys.priv = localizeKey(u.hash(), u.PrivPassphrase, engineID)
+	}
+
+	keyCache.Store(cacheKey, keys)
+	return keys
+}
+
+func localizeKey(newHash func() hash.Hash, passphrase string, engineID []byte) []byte {
+	h := newHash()
+	block := make([]byte, 64)
+	index := 0
+	for count := 0; count < 1048576; count += len(block) {
+		for i := range block {
+			block[i] = passphrase[index%len(passphrase)]
+			index++
+		}
+		h.Write(block)
+	}
+	key := h.Sum(nil)
+
+	h = newHash()
+	h.Write(key)
+	h.Write(engineID)
+	h.Write(key)
+	return h.Sum(nil)
+}
+
+func (u USMUser) digest(message []byte, engineID []byte) []byte {
+	mac := hmac.New(u.hash(), u.keys(engineID).auth)
+	mac.Write(message)
+	return mac.Sum(nil)[:authParametersSize]
+}
+
+var saltCounter uint64
+
+func init() {
+	var seed [8]byte
+	rand.Read(seed[:])
+	saltCounter = binary.BigEndian.Uint64(seed[:])
+}
+
+func (u USMUser) encrypt(plaintext, engineID []byte, boots, time int32) ([]byte, []byte, error) {
+	key := u.keys(engineID).priv
+	salt := make([]byte, 8)
+
+	switch u.PrivProtocol {
+	case PrivDES:
+		binary.BigEndian.PutUint32(salt, uint32(boots))
+		binary.BigEndian.PutUint32(salt[4:], uint32(atomic.AddUint64(&saltCounter, 1)))
+
+		block, err := des.NewCipher(key[:8])
+		if err != nil {
+			return nil, nil, err
+		}
+		iv := make([]byte, des.BlockSize)
+		for i := range iv {
+			iv[i] = key[8+i] ^ salt[i]
+		}
+		if padding := len(plaintext) % des.BlockSize; padding != 0 {
+			plaintext = append(plaintext, make([]byte, des.BlockSize-padding)...)
+		}
+		ciphertext := make([]byte, len(plaintext))
+		cipher.NewCBCEncrypter(block, iv).CryptBlocks(ciphertext, plaintext)
+		return ciphertext, salt, nil
+	case PrivAES:
+		binary.BigEndian.PutUint64(salt, atomic.AddUint64(&saltCounter, 1))
+
+		block, err := aes.NewCipher(key[:16])
+		if err != nil {
+			return nil, nil, err
+		}
+		ciphertext := make([]byte, len(plaintext))
+		cipher.NewCFBEncrypter(block, aesIV(boots, time, salt)).XORKeyStream(ciphertext, plaintext)
+		return ciphertext, salt, nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported priv protocol %s", u.PrivProtocol)
+	}
+}
+
+func (u USMUser) decrypt(ciphertext, engineID []byte, boots, time int32, salt []byte) ([]byte, error) {
+	key := u.keys(engineID).priv
+	if len(salt) != 8 {
+		return nil, fmt.Errorf("invalid privacy parameters")
+	}
+
+	switch u.PrivProtocol {
+	case PrivDES:
+		if len(ciphertext)%des.BlockSize != 0 {
+			return nil, fmt.Errorf("invalid DES ciphertext length")
+		}
+		block, err := des.NewCipher(key[:8])
+		if err != nil {
+			return nil, err
+		}
+		iv := make([]byte, des.BlockSize)
+		for i := range iv {
+			iv[i] = key[8+i] ^ salt[i]
+		}
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, ciphertext)
+		return plaintext, nil
+	case PrivAES:
+		block, err := aes.NewCipher(key[:16])
+		if err != nil {
+			return nil, err
+		}
+		plaintext := make([]byte, len(ciphertext))
+		cipher.NewCFBDecrypter(block, aesIV(boots, time, salt)).XORKeyStream(plaintext, ciphertext)
+		return plaintext, nil
+	default:
+		return nil, fmt.Errorf("unsupported priv protocol %s", u.PrivProtocol)
+	}
+}
+
+func aesIV(boots, time int32, salt []byte) []byte {
+	iv := make([]byte, aes.BlockSize)
+	binary.BigEndian.PutUint32(iv, uint32(boots))
+	binary.BigEndian.PutUint32(iv[4:], uint32(time))
+	copy(iv[8:], salt)
+	return iv
+}
@@ -0,0 +1,43 @@
+package snmp
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"testing"
+)
+
+// Key localization vectors of RFC 3414 appendix A.3.
+func TestLocalizeKey(t *testing.T) {
+	engineID, _ := hex.DecodeString("000000000000000000000002")
+
+	md5Key := localizeKey(md5.New, "maplesyrup", engineID)
+	if hex.EncodeToString(md5Key) != "526f5eed9fcce26f8964c2930787d82b" {
+		t.Fatalf("unexpected MD5 key %x", md5Key)
+	}
+
+	shaKey := localizeKey(sha1.New, "maplesyrup", engineID)
+	if hex.EncodeToString(shaKey) != "6695febc9288e36282235fc7151f128497b38f3f" {
+		t.Fatalf("unexpected SHA key %x", shaKey)
+	}
+}
+
+func TestEncryptRoundTrip(t *testing.T) {
+	engineID, _ := hex.DecodeString("80001f8880e9630000d61ff449")
+	plaintext := []byte("scoped PDU of an odd length")
+
+	for _, privProtocol := range []PrivProtocol{PrivDES, PrivAES} {
+		user := USMUser{Name: "noc", AuthProtocol: AuthSHA, AuthPassphrase: "authpassword", PrivProtocol: privProtocol, PrivPassphrase: "privpassword"}
+		ciphertext, salt, err := user.encrypt(plaintext, engineID, 3, 1200)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decrypted, err := user.decrypt(ciphertext, engineID, 3, 1200, salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(decrypted[:len(plaintext)]) != string(plaintext) {
+			t.Fatalf("%s: got %q", privProtocol, decrypted)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package sshclient
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type AlarmSeverity string
+
+const (
+	AlarmSeverityWarning  AlarmSeverity = "warning"
+	AlarmSeverityMinor    AlarmSeverity = "minor"
+	AlarmSeverityMajor    AlarmSeverity = "major"
+	AlarmSeverityCritical AlarmSeverity = "critical"
+)
+
+func (s AlarmSeverity) level() int {
+	switch s {
+	case AlarmSeverityWarning:
+		return 1
+	case AlarmSeverityMinor:
+		return 2
+	case AlarmSeverityMajor:
+		return 3
+	case AlarmSeverityCritical:
+		return 4
+	default:
+		return 0
+	}
+}
+
+type AlarmType string
+
+const (
+	AlarmTypeFault    AlarmType = "fault"
+	AlarmTypeRecovery AlarmType = "recovery"
+	AlarmTypeEvent    AlarmType = "event"
+)
+
+type Alarm struct {
+	SequenceNumber int               `json:"sequence_number"`
+	Type           AlarmType         `json:"type"`
+	Severity       AlarmSeverity     `json:"severity"`
+	AlarmID        string            `json:"alarm_id"`
+	Category       string            `json:"category"`
+	Name           string            `json:"name"`
+	RaisedAt       *time.Time        `json:"raised_at"`
+	ClearedAt      *time.Time        `json:"cleared_at"`
+	Frame          *int              `json:"frame"`
+	Slot           *int              `json:"slot"`
+	Port           *int              `json:"port"`
+	ONTID          *int              `json:"ont_id"`
+	SerialNumber   string            `json:"serial_number"`
+	Parameters     map[string]string `json:"parameters"`
+	Description    string            `json:"description"`
+	Cause          string            `json:"cause"`
+	Advice         string            `json:"advice"`
+}
+
+// AlarmFilter selects alarms client-side. Zero fields do not filter.
+type AlarmFilter struct {
+	MinSeverity AlarmSeverity
+	Types       []AlarmType
+	Since       time.Time
+	Until       time.Time
+	AlarmIDs    []string
+	Frame       *int
+	Slot        *int
+	Port        *int
+	ONTID       *int
+}
+
+func (f AlarmFilter) Match(alarm Alarm) bool {
+	if f.MinSeverity != "" && alarm.Severity.level() < f.MinSeverity.level() {
+		return false
+	}
+
+	if len(f.Types) > 0 {
+		matched := false
+		for _, alarmType := range f.Types {
+			if alarmType == alarm.Type {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if !f.Since.IsZero() || !f.Until.IsZero() {
+		occurredAt := alarm.RaisedAt
+		if occurredAt == nil {
+			occurredAt = alarm.ClearedAt
+		}
+		if occurredAt == nil {
+			return false
+		}
+		if !f.Since.IsZero() && occurredAt.Before(f.Since) {
+			return false
+		}
+		if !f.Until.IsZero() && occurredAt.After(f.Until) {
+			return false
+		}
+	}
+
+	if len(f.AlarmIDs) > 0 {
+		matched := false
+		for _, id := range f.AlarmIDs {
+			if strings.EqualFold(id, alarm.AlarmID) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	return matchLocation(f.Frame, alarm.Frame) &&
+		matchLocation(f.Slot, alarm.Slot) &&
+		matchLocation(f.Port, alarm.Port) &&
+		matchLocation(f.ONTID, alarm.ONTID)
+}
+
+func (c *CommandExecutor) GetActiveAlarms(filter AlarmFilter) ([]Alarm, error) {
+	return c.getAlarms("display alarm active all", filter)
+}
+
+func (c *CommandExecutor) GetAlarmHistory(filter AlarmFilter) ([]Alarm, error) {
+	return c.getAlarms("display alarm history all", filter)
+}
+
+func (c *CommandExecutor) getAlarms(command string, filter AlarmFilter) ([]Alarm, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.executeCommand(command, false, "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+
+	alarms, err := ParseAlarms(output)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Alarm, 0, len(alarms))
+	for _, alarm := range alarms {
+		if filter.Match(alarm) {
+			results = append(results, alarm)
+		}
+	}
+	return results, nil
+}
+
+var alarmHeaderRegexp = regexp.MustCompile(`^(ALARM|EVENT)\s+(\d+)\s+`)
+
+// ParseAlarms parses alarm records as printed by "display alarm active",
+// "display alarm history" and the asynchronous alarm output of the CLI.
+// Every record starts with an "ALARM <sn> ..." or "EVENT <sn> ..." header
+// followed by "KEY : value" lines and ends with "--- END".
+func ParseAlarms(output string) ([]Alarm, error) {
+	results := make([]Alarm, 0)
+
+	var block []string
+	flush := func() {
+		if alarm, ok := ParseAlarmBlock(block); ok {
+			results = append(results, alarm)
+		}
+		block = nil
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+
+		if block == nil {
+			err := parseFailure(trimmedLine)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if alarmHeaderRegexp.MatchString(trimmedLine) {
+			flush()
+			block = []string{trimmedLine}
+			continue
+		}
+		if block == nil {
+			continue
+		}
+		if strings.HasPrefix(trimmedLine, "--- END") {
+			flush()
+			continue
+		}
+		block = append(block, trimmedLine)
+	}
+	flush()
+
+	return results, nil
+}
+
+// ParseAlarmBlock parses the lines of a single alarm record, header first.
+func ParseAlarmBlock(lines []string) (Alarm, bool) {
+	if len(lines) == 0 {
+		return Alarm{}, false
+	}
+	match := alarmHeaderRegexp.FindStringSubmatch(lines[0])
+	if match == nil {
+		return Alarm{}, false
+	}
+
+	alarm := Alarm{Type: AlarmTypeEvent, Parameters: map[string]string{}}
+	alarm.SequenceNumber, _ = strconv.Atoi(match[2])
+
+	var headerTime *time.Time
+	fields := strings.Fields(lines[0])[2:]
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		switch lower := strings.ToLower(field); {
+		case lower == "fault":
+			alarm.Type = AlarmTypeFault
+		case lower == "recovery":
+			alarm.Type = AlarmTypeRecovery
+		case lower == "event":
+			alarm.Type = AlarmTypeEvent
+		case AlarmSeverity(lower).level() > 0:
+			alarm.Severity = AlarmSeverity(lower)
+		case strings.HasPrefix(lower, "0x"):
+			alarm.AlarmID = lower
+		case dateRegexp.MatchString(field):
+			value := field
+			if i+1 < len(fields) && strings.Contains(fields[i+1], ":") {
+				value += " " + fields[i+1]
+				i++
+			}
+			headerTime = parseOptionalTime(value)
+		default:
+			if alarm.Category == "" {
+				alarm.Category = field
+			}
+		}
+	}
+
+	if alarm.Type == AlarmTypeRecovery {
+		alarm.ClearedAt = headerTime
+	} else {
+		alarm.RaisedAt = headerTime
+	}
+
+	for _, line := range lines[1:] {
+		key, value, ok := splitKeyValue(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "alarmname", "eventname":
+			alarm.Name = value
+		case "parameters":
+			alarm.Parameters = parseAlarmParameters(value)
+		case "description":
+			alarm.Description = value
+		case "cause":
+			alarm.Cause = value
+		case "advice":
+			alarm.Advice = value
+		case "raisetime", "alarmraisetime", "occurtime":
+			alarm.RaisedAt = parseOptionalTime(value)
+		case "cleartime", "alarmcleartime", "recoverytime":
+			alarm.ClearedAt = parseOptionalTime(value)
+		}
+	}
+
+	for key, value := range alarm.Parameters {
+		switch key {
+		case "frameid", "frame":
+			alarm.Frame = parseOptionalInt(value)
+		case "slotid", "slot":
+			alarm.Slot = parseOptionalInt(value)
+		case "portid", "port":
+			alarm.Port = parseOptionalInt(value)
+		case "ontid", "onuid":
+			alarm.ONTID = parseOptionalInt(value)
+		case "ontsn", "sn", "serialnumber":
+			alarm.SerialNumber = value
+		}
+	}
+
+	return alarm, true
+}
+
+var dateRegexp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$|^\d{2}/\d{2}/\d{4}$`)
+
+// parseAlarmParameters parses "FrameID: 0, SlotID: 1, PortID: 0, ONT ID: 1"
+// into a map keyed by normalized parameter name.
+func parseAlarmParameters(value string) map[string]string {
+	parameters := map[string]string{}
+	for _, part := range strings.Split(value, ",") {
+		key, value, ok := splitKeyValue(strings.TrimSpace(part))
+		if ok {
+			parameters[key] = value
+		}
+	}
+	return parameters
+}
+
+func matchLocation(want, got *int) bool {
+	if want == nil {
+		return true
+	}
+	return got != nil && *got == *want
+}
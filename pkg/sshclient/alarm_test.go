@@ -0,0 +1,129 @@
+package sshclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseAlarmsActive(t *testing.T) {
+	alarms, err := ParseAlarms(readTestdata(t, "alarm_active.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alarms) != 3 {
+		t.Fatalf("expected 3 alarms, got %d", len(alarms))
+	}
+
+	alarm := alarms[0]
+	raisedAt := time.Date(2023, 5, 2, 8, 0, 0, 0, time.FixedZone("", 8*60*60))
+	if alarm.SequenceNumber != 5231 || alarm.Type != AlarmTypeFault || alarm.Severity != AlarmSeverityMajor || alarm.AlarmID != "0x2e112001" || alarm.Category != "COMMUNICATIONS" {
+		t.Fatalf("unexpected header %+v", alarm)
+	}
+	if alarm.RaisedAt == nil || !alarm.RaisedAt.Equal(raisedAt) || alarm.ClearedAt != nil {
+		t.Fatalf("unexpected times %v and %v", alarm.RaisedAt, alarm.ClearedAt)
+	}
+	if alarm.Name != "The ONT LOS" || alarm.Description != "The optical signal of the ONT is lost" || alarm.Cause == "" || alarm.Advice == "" {
+		t.Fatalf("unexpected details %+v", alarm)
+	}
+	if *alarm.Frame != 0 || *alarm.Slot != 1 || *alarm.Port != 3 || *alarm.ONTID != 7 || alarm.SerialNumber != "485754430ABCDEF2" {
+		t.Fatalf("unexpected location %+v", alarm.Parameters)
+	}
+	if len(alarm.Parameters) != 5 {
+		t.Fatalf("expected 5 parameters, got %+v", alarm.Parameters)
+	}
+
+	board := alarms[1]
+	if board.Severity != AlarmSeverityCritical || board.Port != nil || board.ONTID != nil || *board.Slot != 2 {
+		t.Fatalf("unexpected board alarm %+v", board)
+	}
+}
+
+func TestParseAlarmsHistory(t *testing.T) {
+	alarms, err := ParseAlarms(readTestdata(t, "alarm_history.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(alarms) != 3 {
+		t.Fatalf("expected 3 alarms, got %d", len(alarms))
+	}
+
+	recovery := alarms[1]
+	if recovery.Type != AlarmTypeRecovery || recovery.RaisedAt != nil || recovery.ClearedAt == nil {
+		t.Fatalf("unexpected recovery %+v", recovery)
+	}
+	event := alarms[2]
+	if event.Type != AlarmTypeEvent || event.Name != "The ONT is online" || event.SerialNumber != "" {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
+
+func TestParseAlarmBlock(t *testing.T) {
+	alarm, ok := ParseAlarmBlock([]string{
+		"ALARM  77  FAULT  MINOR  0x2e112001 COMMUNICATIONS  2023-05-02 08:00:00+08:00",
+		"ALARM NAME  : The ONT LOS",
+		"PARAMETERS  : FrameID: 0, SlotID: 1, PortID: 3, ONT ID: 7",
+	})
+	if !ok {
+		t.Fatal("expected the block to be parsed")
+	}
+	expected := map[string]string{"frameid": "0", "slotid": "1", "portid": "3", "ontid": "7"}
+	for key, value := range expected {
+		if alarm.Parameters[key] != value {
+			t.Fatalf("parameter %s: got %q, want %q", key, alarm.Parameters[key], value)
+		}
+	}
+	if alarm.Severity != AlarmSeverityMinor || *alarm.ONTID != 7 {
+		t.Fatalf("unexpected alarm %+v", alarm)
+	}
+
+	if _, ok := ParseAlarmBlock([]string{"ALARM NAME  : The ONT LOS"}); ok {
+		t.Fatal("expected a block without header to be rejected")
+	}
+}
+
+func TestGetAlarmsFilters(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display alarm active all":  readTestdata(t, "alarm_active.txt"),
+		"display alarm history all": readTestdata(t, "alarm_history.txt"),
+	})
+
+	slot, port := 1, 3
+	tests := []struct {
+		name      string
+		command   func(AlarmFilter) ([]Alarm, error)
+		filter    AlarmFilter
+		sequences []int
+	}{
+		{"active", executor.GetActiveAlarms, AlarmFilter{}, []int{5231, 5240, 5244}},
+		{"minimum severity", executor.GetActiveAlarms, AlarmFilter{MinSeverity: AlarmSeverityMajor}, []int{5231, 5240}},
+		{"location", executor.GetActiveAlarms, AlarmFilter{Slot: &slot, Port: &port}, []int{5231}},
+		{"alarm id", executor.GetActiveAlarms, AlarmFilter{AlarmIDs: []string{"0X2E112003"}}, []int{5244}},
+		{"type", executor.GetAlarmHistory, AlarmFilter{Types: []AlarmType{AlarmTypeFault, AlarmTypeRecovery}}, []int{5120, 5121}},
+		{"since", executor.GetAlarmHistory, AlarmFilter{Since: time.Date(2023, 5, 1, 10, 0, 5, 0, time.UTC)}, []int{5121, 5122}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			alarms, err := test.command(test.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sequences := make([]int, 0, len(alarms))
+			for _, alarm := range alarms {
+				sequences = append(sequences, alarm.SequenceNumber)
+			}
+			if len(sequences) != len(test.sequences) {
+				t.Fatalf("got %v, want %v", sequences, test.sequences)
+			}
+			for i := range sequences {
+				if sequences[i] != test.sequences[i] {
+					t.Fatalf("got %v, want %v", sequences, test.sequences)
+				}
+			}
+		})
+	}
+
+	if len(terminal.commands) != len(tests) {
+		t.Fatalf("expected one command per call, got %q", terminal.commands)
+	}
+}
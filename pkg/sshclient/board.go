@@ -0,0 +1,221 @@
+package sshclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Board struct {
+	Frame       int         `json:"frame"`
+	Slot        int         `json:"slot"`
+	Name        string      `json:"name"`
+	Status      string      `json:"status"`
+	SubType0    string      `json:"sub_type_0"`
+	SubType1    string      `json:"sub_type_1"`
+	OnlineState string      `json:"online_state"`
+	Ports       []BoardPort `json:"ports,omitempty"`
+}
+
+// gponBoardFamilies are the GPON, XG-PON and XGS-PON service board families,
+// named without the "H80x"/"H90x" hardware prefix.
+var gponBoardFamilies = []string{
+	"GPBC", "GPBD", "GPBH", "GPFD", "GPHF", "GPLF", "GPSF", "GPUF",
+	"XGBD", "XGHD", "XSHD", "XSHF", "TWED",
+}
+
+// IsGPON reports whether the board belongs to one of the GPON board
+// families, such as H805GPFD, H901GPHF or H901XGHD.
+func (b *Board) IsGPON() bool {
+	name := strings.ToUpper(b.Name)
+	if len(name) > 4 && name[0] == 'H' && isNumber(name[1:4]) {
+		name = name[4:]
+	}
+	for _, family := range gponBoardFamilies {
+		if strings.HasPrefix(name, family) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRunning reports whether the board status is Normal, Active_normal or
+// Standby_normal.
+func (b *Board) IsRunning() bool {
+	status := strings.ToLower(b.Status)
+	return status == "normal" || strings.HasSuffix(status, "_normal")
+}
+
+type BoardPort struct {
+	FSP                 string `json:"fsp"`
+	Port                int    `json:"port"`
+	Type                string `json:"type"`
+	OpticalModuleStatus string `json:"optical_module_status"`
+	LaserState          string `json:"laser_state"`
+}
+
+func (p *BoardPort) GetFrameSlotPort() (int, int, int, error) {
+	return getFrameSlotPortFromFSP(p.FSP)
+}
+
+func (p *BoardPort) IsGPON() bool {
+	return strings.Contains(strings.ToUpper(p.Type), "PON")
+}
+
+func (c *CommandExecutor) GetBoards(frame int) ([]Board, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display board %d", frame), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseBoards(output, frame)
+}
+
+func (c *CommandExecutor) GetBoard(frame, slot int) (*Board, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display board %d/%d", frame, slot), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseBoard(output, frame, slot)
+}
+
+// DiscoverGPONPorts returns every PON port of the running GPON boards
+// installed in the frame, reading the port list of each board.
+func (c *CommandExecutor) DiscoverGPONPorts(frame int) ([]BoardPort, error) {
+	boards, err := c.GetBoards(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := make([]BoardPort, 0)
+	for _, board := range boards {
+		if !board.IsGPON() || !board.IsRunning() {
+			continue
+		}
+		detail, err := c.GetBoard(frame, board.Slot)
+		if err != nil {
+			return nil, err
+		}
+		for _, port := range detail.Ports {
+			if port.IsGPON() {
+				ports = append(ports, port)
+			}
+		}
+	}
+
+	return ports, nil
+}
+
+// ParseBoards parses "display board F". Empty slots are skipped.
+func ParseBoards(output string, frame int) ([]Board, error) {
+	results := make([]Board, 0)
+
+	for _, line := range strings.Split(output, "\n") {
+		err := parseFailure(line)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !isNumber(fields[0]) {
+			continue
+		}
+
+		slot, _ := strconv.Atoi(fields[0])
+		board := Board{
+			Frame:  frame,
+			Slot:   slot,
+			Name:   fields[1],
+			Status: fields[2],
+		}
+
+		rest := fields[3:]
+		if len(rest) > 0 && isOnlineState(rest[len(rest)-1]) {
+			board.OnlineState = rest[len(rest)-1]
+			rest = rest[:len(rest)-1]
+		}
+		if len(rest) > 0 {
+			board.SubType0 = rest[0]
+		}
+		if len(rest) > 1 {
+			board.SubType1 = rest[1]
+		}
+
+		results = append(results, board)
+	}
+
+	return results, nil
+}
+
+// ParseBoard parses "display board F/S": the "Key : value" header of the
+// board followed by one row per port holding its number, type and the state
+// of its optical module and laser.
+func ParseBoard(output string, frame, slot int) (*Board, error) {
+	board := &Board{Frame: frame, Slot: slot, Ports: make([]BoardPort, 0)}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		if key, value, ok := splitKeyValue(trimmedLine); ok {
+			switch key {
+			case "boardname":
+				board.Name = value
+			case "boardstatus":
+				board.Status = value
+			case "online/offline":
+				board.OnlineState = value
+			case "subtype0":
+				board.SubType0 = value
+			case "subtype1":
+				board.SubType1 = value
+			}
+			continue
+		}
+
+		fields := strings.Fields(trimmedLine)
+		if len(fields) < 2 || !isNumber(fields[0]) || !isPortType(fields[1]) {
+			continue
+		}
+
+		port, _ := strconv.Atoi(fields[0])
+		boardPort := BoardPort{
+			FSP:  fmt.Sprintf("%d/%d/%d", frame, slot, port),
+			Port: port,
+			Type: fields[1],
+		}
+		for _, field := range fields[2:] {
+			switch strings.ToLower(field) {
+			case "online", "offline", "absent", "in-position", "not-in-position":
+				boardPort.OpticalModuleStatus = field
+			case "normal", "shutdown", "abnormal", "on", "off", "auto":
+				boardPort.LaserState = field
+			}
+		}
+
+		board.Ports = append(board.Ports, boardPort)
+	}
+
+	return board, nil
+}
+
+func isOnlineState(value string) bool {
+	return strings.EqualFold(value, "online") || strings.EqualFold(value, "offline")
+}
+
+func isPortType(value string) bool {
+	switch strings.ToUpper(value) {
+	case "GPON", "XG-PON", "XGS-PON", "EPON", "10G-EPON", "GE", "FE", "10GE", "ETH", "XGPON", "XGSPON":
+		return true
+	}
+	return false
+}
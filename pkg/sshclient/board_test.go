@@ -0,0 +1,39 @@
+package sshclient
+
+import "testing"
+
+func TestBoardIsGPON(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"H805GPFD": true,
+		"H806GPBH": true,
+		"H901GPHF": true,
+		"H901XGHD": true,
+		"H901TWED": true,
+		"H801SCUN": false,
+		"H801GICF": false,
+		"H802EPBD": false,
+		"H801X2CS": false,
+	} {
+		board := Board{Name: name}
+		if board.IsGPON() != expected {
+			t.Errorf("IsGPON(%q) = %v, want %v", name, !expected, expected)
+		}
+	}
+}
+
+func TestDiscoverGPONPortsSkipsBoardsNotRunning(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display board 0":   readTestdata(t, "display_board_frame.txt"),
+		"display board 0/0": readTestdata(t, "display_board_slot.txt"),
+		"display board 0/1": readTestdata(t, "display_board_slot.txt"),
+	})
+
+	ports, err := executor.DiscoverGPONPorts(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display board 0", "display board 0/0", "display board 0/1")
+	if len(ports) != 6 || ports[0].FSP != "0/0/0" || ports[5].FSP != "0/1/2" || ports[5].OpticalModuleStatus != "Absent" {
+		t.Fatalf("unexpected ports %+v", ports)
+	}
+}
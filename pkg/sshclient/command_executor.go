@@ -6,6 +6,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 )
 
 type ExecutorContext struct {
@@ -20,10 +21,27 @@ type CommandExecutor struct {
 	Stdin             io.WriteCloser
 	ExecutorContext   ExecutorContext
 	ConnectionManager *ConnectionManager
+	Dialect           Dialect
+	FilterUnsolicited bool
+	writeMu           sync.Mutex
 }
 
 type CommandExecutorOptions struct {
 	Verbose bool
+	Dialect Dialect
+	// FilterUnsolicited removes alarm and event notifications printed in the
+	// middle of command output, for sessions that have alarm output enabled.
+	FilterUnsolicited bool
+}
+
+// ONTReader is the read-only ONT view implemented by both CommandExecutor
+// and the SNMP client in pkg/snmp, so callers can switch backends.
+// The port-wide methods are keyed by ONT ID.
+type ONTReader interface {
+	GetGeneralInfo(frame, slot, port, ontID int) (*GeneralInfo, error)
+	GetONTOpticalInfo(frame, slot, port, ontID int) (*OpticalInfo, error)
+	GetPortGeneralInfo(frame, slot, port int) (map[int]*GeneralInfo, error)
+	GetPortOpticalInfo(frame, slot, port int) (map[int]*OpticalInfo, error)
 }
 
 func NewCommandExecutor(connManager *ConnectionManager, options CommandExecutorOptions) (*CommandExecutor, error) {
@@ -48,6 +66,8 @@ func NewCommandExecutor(connManager *ConnectionManager, options CommandExecutorO
 		ExecutorContext:   ExecutorContext{},
 		Verbose:           options.Verbose,
 		ConnectionManager: connManager,
+		Dialect:           options.Dialect,
+		FilterUnsolicited: options.FilterUnsolicited,
 	}
 
 	_, err = commExecutor.readOutputUntilPrompt(">")
@@ -69,16 +89,27 @@ func NewCommandExecutor(connManager *ConnectionManager, options CommandExecutorO
 }
 
 func (c *CommandExecutor) ExecuteCommand(command, prompt string) (string, error) {
-	_, err := c.Stdin.Write([]byte(command + "\n"))
+	return c.executeCommand(command, c.FilterUnsolicited, prompt)
+}
+
+// executeCommand runs command and waits for any of prompts. When
+// filterUnsolicited is set, alarm and event notifications the OLT printed in
+// the middle of the output are removed from it.
+func (c *CommandExecutor) executeCommand(command string, filterUnsolicited bool, prompts ...string) (string, error) {
+	err := c.write(command + "\n")
 	if err != nil {
 		return "", err
 	}
 
-	output, err := c.readOutputUntilPrompt(prompt)
+	output, err := c.readOutputUntilPrompt(prompts...)
 	if err != nil {
 		return "", err
 	}
 
+	if filterUnsolicited {
+		output = stripUnsolicitedMessages(output)
+	}
+
 	if c.Verbose {
 		fmt.Print(output)
 	}
@@ -116,6 +147,165 @@ func (c *CommandExecutor) GetOpticalInfo(port, ontID int) (*OpticalInfo, error)
 	return ParseOpticalInfo(output)
 }
 
+// GetONTOpticalInfo is GetOpticalInfo addressed by F/S/P.
+func (c *CommandExecutor) GetONTOpticalInfo(frame, slot, port, ontID int) (*OpticalInfo, error) {
+	var info *OpticalInfo
+	err := c.inInterfaceGPONMode(frame, slot, func() error {
+		var err error
+		info, err = c.GetOpticalInfo(port, ontID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+// GetPortGeneralInfo fills the fields printed by "display ont info summary"
+// for every ONT on a port.
+func (c *CommandExecutor) GetPortGeneralInfo(frame, slot, port int) (map[int]*GeneralInfo, error) {
+	summary, err := c.GetONTSummary(frame, slot, port)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := map[int]*GeneralInfo{}
+	for _, ont := range summary.ONTs {
+		id, err := strconv.Atoi(ont.ID)
+		if err != nil {
+			continue
+		}
+		infos[id] = &GeneralInfo{
+			FSP:          summary.FSP,
+			ID:           ont.ID,
+			RunState:     ont.RunState,
+			Distance:     ont.Distance,
+			SN:           ont.SN,
+			Description:  ont.Description,
+			LatDownCause: ont.LastDownCause,
+			LastUpTime:   ont.LastUpTime,
+			LastDownTime: ont.LastDownTime,
+		}
+	}
+	return infos, nil
+}
+
+// GetPortOpticalInfo fills the readings printed by "display ont optical-info
+// P all" for every ONT on a port; thresholds are left empty.
+func (c *CommandExecutor) GetPortOpticalInfo(frame, slot, port int) (map[int]*OpticalInfo, error) {
+	var readings []ONTOpticalReading
+	err := c.inInterfaceGPONMode(frame, slot, func() error {
+		var err error
+		readings, err = c.GetPortOpticalReadings(port)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	infos := map[int]*OpticalInfo{}
+	for _, reading := range readings {
+		infos[reading.ONTID] = &OpticalInfo{
+			RxOpticalPower:       formatOptionalFloat(reading.RxPower),
+			TxOpticalPower:       formatOptionalFloat(reading.TxPower),
+			OLTRxONTOpticalPower: formatOptionalFloat(reading.OLTRxPower),
+			Temperature:          formatOptionalFloat(reading.Temperature),
+			Voltage:              formatOptionalFloat(reading.Voltage),
+			LaserBiasCurrent:     formatOptionalFloat(reading.BiasCurrent),
+		}
+	}
+	return infos, nil
+}
+
+// inInterfaceGPONMode runs fn in the interface gpon mode of frame/slot and
+// returns to the mode the executor was in, quitting another board first.
+func (c *CommandExecutor) inInterfaceGPONMode(frame, slot int, fn func() error) error {
+	previous := c.ExecutorContext
+	if previous.Level == 3 && previous.Frame == frame && previous.Slot == slot {
+		return fn()
+	}
+
+	if previous.Level == 3 {
+		if err := c.ExitCommandLevel(); err != nil {
+			return err
+		}
+	}
+	if err := c.EnterInterfaceGPONMode(frame, slot); err != nil {
+		return err
+	}
+
+	err := fn()
+	restoreErr := c.ExitCommandLevel()
+	if restoreErr == nil && previous.Level == 3 {
+		restoreErr = c.EnterInterfaceGPONMode(previous.Frame, previous.Slot)
+	}
+	if err != nil {
+		return err
+	}
+	return restoreErr
+}
+
+func (c *CommandExecutor) GetONTEthernetPortStates(port, ontID int) ([]ONTEthernetPortState, error) {
+	if c.ExecutorContext.Level != 3 {
+		return nil, fmt.Errorf("not in interface gpon mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont port state %d %d eth-port all", port, ontID), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseONTEthernetPortStates(output)
+}
+
+func (c *CommandExecutor) GetPortOpticalReadings(port int) ([]ONTOpticalReading, error) {
+	if c.ExecutorContext.Level != 3 {
+		return nil, fmt.Errorf("not in interface gpon mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont optical-info %d all", port), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseONTOpticalReadings(output, fmt.Sprintf("%d/%d/%d", c.ExecutorContext.Frame, c.ExecutorContext.Slot, port))
+}
+
+func (c *CommandExecutor) GetBoardOpticalReadings() ([]ONTOpticalReading, error) {
+	if c.ExecutorContext.Level != 3 {
+		return nil, fmt.Errorf("not in interface gpon mode")
+	}
+
+	prompt := fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot)
+	dialect, err := c.detectDialect(prompt)
+	if err != nil {
+		return nil, err
+	}
+
+	if dialect == DialectMA5800 {
+		output, err := c.ExecuteCommand("display ont optical-info all", prompt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to run command: %v", err)
+		}
+		return ParseONTOpticalReadings(output, "")
+	}
+
+	output, err := c.ExecuteCommand(fmt.Sprintf("display board %d/%d", c.ExecutorContext.Frame, c.ExecutorContext.Slot), prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	board, err := ParseBoard(output, c.ExecutorContext.Frame, c.ExecutorContext.Slot)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ONTOpticalReading, 0)
+	for _, port := range board.Ports {
+		readings, err := c.GetPortOpticalReadings(port.Port)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, readings...)
+	}
+	return results, nil
+}
+
 func (c *CommandExecutor) GetGeneralInfoBySn(sn string) (*GeneralInfo, error) {
 	if c.ExecutorContext.Level != 2 {
 		return nil, fmt.Errorf("not in config mode")
@@ -127,6 +317,115 @@ func (c *CommandExecutor) GetGeneralInfoBySn(sn string) (*GeneralInfo, error) {
 	return ParseGeneralInfoBySn(output)
 }
 
+func (c *CommandExecutor) GetGeneralInfo(frame, slot, port, ontID int) (*GeneralInfo, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont info %d %d %d %d", frame, slot, port, ontID), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseGeneralInfo(output)
+}
+
+func (c *CommandExecutor) GetGeneralInfoByDescription(description string) ([]GeneralInfo, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	err := validateDescription(description)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont info by-desc %s", quote(description)), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseGeneralInfoList(output)
+}
+
+func (c *CommandExecutor) GetGeneralInfoByMAC(mac string) (*GeneralInfo, error) {
+	location, err := c.GetMACLocation(mac)
+	if err != nil {
+		return nil, err
+	}
+	if location.PortType != "" && location.PortType != "gpon" {
+		return nil, NotFoundError{}
+	}
+
+	frame, slot, port, err := location.GetFrameSlotPort()
+	if err != nil {
+		return nil, err
+	}
+	return c.GetGeneralInfo(frame, slot, port, location.ONTID)
+}
+
+func (c *CommandExecutor) GetONTVersion(frame, slot, port, ontID int) (*ONTVersion, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont version %d %d %d %d", frame, slot, port, ontID), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseONTVersion(output)
+}
+
+func (c *CommandExecutor) GetPortONTVersions(frame, slot, port int) ([]ONTVersion, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont version %d %d %d all", frame, slot, port), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseONTVersions(output)
+}
+
+func (c *CommandExecutor) GetBoardONTVersions(frame, slot int) ([]ONTVersion, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+
+	dialect, err := c.detectDialect("(config)#")
+	if err != nil {
+		return nil, err
+	}
+
+	if dialect == DialectMA5800 {
+		output, err := c.ExecuteCommand(fmt.Sprintf("display ont version %d %d all", frame, slot), "(config)#")
+		if err != nil {
+			return nil, fmt.Errorf("failed to run command: %v", err)
+		}
+		return ParseONTVersions(output)
+	}
+
+	board, err := c.GetBoard(frame, slot)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]ONTVersion, 0)
+	for _, port := range board.Ports {
+		versions, err := c.GetPortONTVersions(frame, slot, port.Port)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, versions...)
+	}
+	return results, nil
+}
+
+func (c *CommandExecutor) GetONTSummary(frame, slot, port int) (*ONTSummary, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont info summary %d/%d/%d", frame, slot, port), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseONTSummary(output)
+}
+
 func (c *CommandExecutor) GetServicePorts(frame, slot, port, ontID int) ([]ServicePort, error) {
 	if c.ExecutorContext.Level != 2 {
 		return nil, fmt.Errorf("not in config mode")
@@ -138,6 +437,18 @@ func (c *CommandExecutor) GetServicePorts(frame, slot, port, ontID int) ([]Servi
 	return ParseServicePorts(output)
 }
 
+func (c *CommandExecutor) StreamAllServicePorts(handle func(ServicePort) error) error {
+	return c.streamServicePorts("display service-port all", handle)
+}
+
+func (c *CommandExecutor) StreamServicePortsByVlan(vlan int, handle func(ServicePort) error) error {
+	return c.streamServicePorts(fmt.Sprintf("display service-port vlan %d", vlan), handle)
+}
+
+func (c *CommandExecutor) StreamServicePortsByBoard(frame, slot int, handle func(ServicePort) error) error {
+	return c.streamServicePorts(fmt.Sprintf("display service-port board %d/%d", frame, slot), handle)
+}
+
 func (c *CommandExecutor) EnterInterfaceGPONMode(frame int, slot int) error {
 	if c.ExecutorContext.Level != 2 {
 		return fmt.Errorf("not in config mode")
@@ -153,16 +464,27 @@ func (c *CommandExecutor) EnterInterfaceGPONMode(frame int, slot int) error {
 }
 
 func (c *CommandExecutor) AddOpticalNetworkTerminal(port int, sn string, description string) (int, error) {
-	if c.ExecutorContext.Level != 3 {
-		return 0, fmt.Errorf("not in interface gpon mode")
-	}
-
-	output, err := c.ExecuteCommand(fmt.Sprintf("ont add %d sn-auth %s omci ont-lineprofile-id 60 ont-srvprofile-id 35 desc %s",
+	return c.addOpticalNetworkTerminal(fmt.Sprintf("ont add %d sn-auth %s omci ont-lineprofile-id 60 ont-srvprofile-id 35 desc %s",
 		port,
 		strings.Split(sn, " ")[0],
 		description,
-	), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	))
+}
 
+func (c *CommandExecutor) AddOpticalNetworkTerminalWithRequest(req AddONTRequest) (int, error) {
+	command, err := req.command()
+	if err != nil {
+		return 0, err
+	}
+	return c.addOpticalNetworkTerminal(command)
+}
+
+func (c *CommandExecutor) addOpticalNetworkTerminal(command string) (int, error) {
+	if c.ExecutorContext.Level != 3 {
+		return 0, fmt.Errorf("not in interface gpon mode")
+	}
+
+	output, err := c.ExecuteCommand(command, fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
 	if err != nil {
 		return 0, fmt.Errorf("failed to run command: %v", err)
 	}
@@ -205,16 +527,31 @@ func (c *CommandExecutor) DeleteOpticalNetworkTerminal(port int) error {
 }
 
 func (c *CommandExecutor) AddNativeVirtualLan(port, ontID int, mode string) error {
+	ontPort := ONTPort{Type: ONTPortETH, Number: 1}
+	if mode == "router" {
+		ontPort = ONTPort{Type: ONTPortIPHost}
+	}
+
+	return c.SetONTPortNativeVlan(NativeVlanSpec{
+		Port:     port,
+		ONTID:    ontID,
+		ONTPort:  ontPort,
+		Vlan:     20,
+		Priority: 0,
+	})
+}
+
+func (c *CommandExecutor) SetONTPortNativeVlan(spec NativeVlanSpec) error {
 	if c.ExecutorContext.Level != 3 {
 		return fmt.Errorf("not in interface gpon mode")
 	}
 
-	ontType := "eth 1"
-	if mode == "router" {
-		ontType = "iphost"
+	command, err := spec.command()
+	if err != nil {
+		return err
 	}
 
-	output, err := c.ExecuteCommand(fmt.Sprintf("ont port native-vlan %d %d %s vlan 20 priority 0", port, ontID, ontType), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	output, err := c.ExecuteCommand(command, fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
 	if err != nil {
 		return fmt.Errorf("failed to run command: %v", err)
 	}
@@ -228,12 +565,124 @@ func (c *CommandExecutor) AddNativeVirtualLan(port, ontID int, mode string) erro
 	return nil
 }
 
+func (c *CommandExecutor) UndoONTPortNativeVlan(port, ontID int, ontPort ONTPort) error {
+	if c.ExecutorContext.Level != 3 {
+		return fmt.Errorf("not in interface gpon mode")
+	}
+
+	err := ontPort.Validate()
+	if err != nil {
+		return err
+	}
+
+	output, err := c.ExecuteCommand(fmt.Sprintf("undo ont port native-vlan %d %d %s", port, ontID, ontPort), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	if err != nil {
+		return fmt.Errorf("failed to run command: %v", err)
+	}
+
+	lines := strings.Split(output, "\n")
+	err = parseLinesFailure(lines)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GetONTPortNativeVlans lists the native VLAN of every Ethernet port and the
+// VLAN of every IP host of the ONT, the latter read from "display ont ipconfig".
+func (c *CommandExecutor) GetONTPortNativeVlans(port, ontID int) ([]ONTPortNativeVlan, error) {
+	if c.ExecutorContext.Level != 3 {
+		return nil, fmt.Errorf("not in interface gpon mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont port attribute %d %d eth", port, ontID), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	nativeVlans, err := ParseONTPortNativeVlans(output)
+	if err != nil {
+		return nil, err
+	}
+
+	configs, err := c.GetONTIPConfig(port, ontID)
+	if err != nil {
+		return nil, err
+	}
+	for _, config := range configs {
+		if config.Vlan == 0 {
+			continue
+		}
+		nativeVlans = append(nativeVlans, ONTPortNativeVlan{
+			ONTID:      ontID,
+			PortID:     config.Index,
+			PortType:   string(ONTPortIPHost),
+			NativeVlan: config.Vlan,
+			Priority:   config.Priority,
+		})
+	}
+
+	return nativeVlans, nil
+}
+
 func (c *CommandExecutor) AddServicePort(vlan, frame, slot, port, ontID int) error {
+	return c.addServicePort(fmt.Sprintf("service-port vlan %d gpon %d/%d/%d ont %d gemport 20 multi-service user-vlan 20 tag-transform translate inbound traffic-table index 10 outbound traffic-table index 10", vlan, frame, slot, port, ontID))
+}
+
+// AddServicePortWithSpec returns the index of the new service port. When
+// spec.Index is nil the OLT picks the index, which is the one that appears
+// among the service ports of the ONT after creation.
+func (c *CommandExecutor) AddServicePortWithSpec(spec ServicePortSpec) (int, error) {
+	command, err := spec.command()
+	if err != nil {
+		return 0, err
+	}
+
+	if spec.Index != nil {
+		return *spec.Index, c.addServicePort(command)
+	}
+
+	before, err := c.GetServicePorts(spec.Frame, spec.Slot, spec.Port, spec.ONTID)
+	if err != nil {
+		return 0, err
+	}
+	existing := make(map[int]bool, len(before))
+	for _, servicePort := range before {
+		existing[servicePort.Index] = true
+	}
+
+	err = c.addServicePort(command)
+	if err != nil {
+		return 0, err
+	}
+
+	after, err := c.GetServicePorts(spec.Frame, spec.Slot, spec.Port, spec.ONTID)
+	if err != nil {
+		return 0, err
+	}
+
+	index := -1
+	for _, servicePort := range after {
+		if existing[servicePort.Index] {
+			continue
+		}
+		if index >= 0 {
+			return 0, fmt.Errorf("several service ports appeared on ONT %d/%d/%d %d", spec.Frame, spec.Slot, spec.Port, spec.ONTID)
+		}
+		index = servicePort.Index
+	}
+	if index < 0 {
+		return 0, fmt.Errorf("service port not found after creation")
+	}
+
+	return index, nil
+}
+
+func (c *CommandExecutor) addServicePort(command string) error {
 	if c.ExecutorContext.Level != 2 {
 		return fmt.Errorf("not in config mode")
 	}
 
-	output, err := c.ExecuteCommand(fmt.Sprintf("service-port vlan %d gpon %d/%d/%d ont %d gemport 20 multi-service user-vlan 20 tag-transform translate inbound traffic-table index 10 outbound traffic-table index 10", vlan, frame, slot, port, ontID), "(config)#")
+	output, err := c.ExecuteCommand(command, "(config)#")
 	if err != nil {
 		return fmt.Errorf("failed to run command: %v", err)
 	}
@@ -266,6 +715,32 @@ func (c *CommandExecutor) UndoServicePort(id int) error {
 	return nil
 }
 
+func (c *CommandExecutor) streamServicePorts(command string, handle func(ServicePort) error) error {
+	if c.ExecutorContext.Level != 2 {
+		return fmt.Errorf("not in config mode")
+	}
+
+	err := c.write(command + "\n")
+	if err != nil {
+		return fmt.Errorf("failed to run command: %v", err)
+	}
+
+	return c.streamOutputUntilPrompt("(config)#", func(line string) error {
+		if strings.Contains(line, "Failure: No service virtual port can be operated") {
+			return nil
+		}
+		err := parseFailure(line)
+		if err != nil {
+			return err
+		}
+		servicePort, ok := ParseServicePortLine(line)
+		if !ok {
+			return nil
+		}
+		return handle(servicePort)
+	})
+}
+
 func (c *CommandExecutor) quit(exit bool) error {
 	var err error
 
@@ -310,38 +785,119 @@ func (c *CommandExecutor) quit(exit bool) error {
 	return nil
 }
 
-func (c *CommandExecutor) readOutputUntilPrompt(prompt string) (string, error) {
+func (c *CommandExecutor) readOutputUntilPrompt(prompts ...string) (string, error) {
 	output := make([]byte, 4096)
 	var accumulatedOutput []byte
 	for {
-		n, err := c.Stdout.Read(output)
+		text, err := c.readChunk(output)
 		if err != nil {
 			if err == io.EOF {
 				break
 			}
-			return "", fmt.Errorf("failed to read output: %v", err)
+			return "", err
 		}
 
-		buffer := output[:n]
-		text := string(buffer)
+		accumulatedOutput = append(accumulatedOutput, []byte(text)...)
+		if containsAny(string(accumulatedOutput), prompts) {
+			break
+		}
+	}
+	return string(accumulatedOutput), nil
+}
 
-		if strings.Contains(text, "---- More ( Press 'Q' to break ) ----") || strings.Contains(text, " }:") {
-			_, err := c.Stdin.Write([]byte("\n"))
-			if err != nil {
-				return "", err
+// streamOutputUntilPrompt hands every complete output line to handle as soon
+// as it is read instead of accumulating the whole output. Once handle returns
+// an error the remaining output is drained so the session stays usable.
+func (c *CommandExecutor) streamOutputUntilPrompt(prompt string, handle func(line string) error) error {
+	output := make([]byte, 4096)
+	var pending string
+	var handleErr error
+	inUnsolicited := false
+	for {
+		text, err := c.readChunk(output)
+		if err != nil {
+			if err == io.EOF {
+				break
 			}
+			return err
 		}
 
-		text = strings.Replace(text, "---- More ( Press 'Q' to break ) ----", "", -1)
-		re := regexp.MustCompile(`.\[37D`)
-		text = re.ReplaceAllString(text, "")
+		pending += text
+		lines := strings.Split(pending, "\n")
+		pending = lines[len(lines)-1]
 
-		accumulatedOutput = append(accumulatedOutput, []byte(text)...)
-		if strings.Contains(string(accumulatedOutput), prompt) {
+		for _, line := range lines[:len(lines)-1] {
+			if c.Verbose {
+				fmt.Println(line)
+			}
+			if c.FilterUnsolicited && unsolicitedHeaderRegexp.MatchString(line) {
+				inUnsolicited = true
+			}
+			if inUnsolicited {
+				inUnsolicited = !strings.Contains(line, "--- END")
+				continue
+			}
+			if handleErr == nil {
+				handleErr = handle(line)
+			}
+		}
+
+		if strings.Contains(pending, prompt) {
 			break
 		}
 	}
-	return string(accumulatedOutput), nil
+
+	if c.Verbose {
+		fmt.Print(pending)
+	}
+	if handleErr == nil && pending != "" {
+		handleErr = handle(pending)
+	}
+	return handleErr
+}
+
+// write sends data to the session. The paging newlines of readChunk and the
+// keepalive of AlarmMonitor run alongside commands, so writes are serialised.
+func (c *CommandExecutor) write(data string) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	_, err := c.Stdin.Write([]byte(data))
+	return err
+}
+
+func containsAny(output string, prompts []string) bool {
+	for _, prompt := range prompts {
+		if strings.Contains(output, prompt) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *CommandExecutor) readChunk(output []byte) (string, error) {
+	n, err := c.Stdout.Read(output)
+	if err != nil {
+		if err == io.EOF {
+			return "", err
+		}
+		return "", fmt.Errorf("failed to read output: %v", err)
+	}
+
+	buffer := output[:n]
+	text := string(buffer)
+
+	if strings.Contains(text, "---- More ( Press 'Q' to break ) ----") || strings.Contains(text, " }:") {
+		err := c.write("\n")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	text = strings.Replace(text, "---- More ( Press 'Q' to break ) ----", "", -1)
+	re := regexp.MustCompile(`.\[37D`)
+	text = re.ReplaceAllString(text, "")
+
+	return text, nil
 }
 
 func (c *CommandExecutor) enable() error {
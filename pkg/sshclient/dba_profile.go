@@ -0,0 +1,279 @@
+package sshclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+type DBAType int
+
+const (
+	DBAType1 DBAType = 1
+	DBAType2 DBAType = 2
+	DBAType3 DBAType = 3
+	DBAType4 DBAType = 4
+	DBAType5 DBAType = 5
+)
+
+// DBAProfile is a GPON upstream bandwidth profile. Bandwidths are in kbit/s.
+type DBAProfile struct {
+	ID                    int     `json:"id"`
+	Name                  string  `json:"name"`
+	Type                  DBAType `json:"type"`
+	BandwidthCompensation bool    `json:"bandwidth_compensation"`
+	Fix                   int     `json:"fix"`
+	Assure                int     `json:"assure"`
+	Max                   int     `json:"max"`
+	BindTimes             int     `json:"bind_times"`
+}
+
+// DBAProfileRequest describes a "dba-profile add" or "dba-profile modify"
+// command. Which of Fix, Assure and Max apply depends on Type: type1 fix,
+// type2 assure, type3 assure and max, type4 max, type5 all three.
+type DBAProfileRequest struct {
+	ProfileID   *int
+	ProfileName string
+	Type        DBAType
+	Fix         int
+	Assure      int
+	Max         int
+}
+
+func (r DBAProfileRequest) Validate() error {
+	if r.ProfileID == nil && r.ProfileName == "" {
+		return InvalidRequestError{Field: "ProfileID", Reason: "an ID or a name is required"}
+	}
+	if r.ProfileID != nil && *r.ProfileID < 0 {
+		return InvalidRequestError{Field: "ProfileID", Reason: "must not be negative"}
+	}
+	if err := validateName("ProfileName", r.ProfileName); err != nil {
+		return err
+	}
+
+	switch r.Type {
+	case DBAType1:
+		if r.Fix <= 0 {
+			return InvalidRequestError{Field: "Fix", Reason: "must be positive for type1"}
+		}
+	case DBAType2:
+		if r.Assure <= 0 {
+			return InvalidRequestError{Field: "Assure", Reason: "must be positive for type2"}
+		}
+	case DBAType3:
+		if r.Assure <= 0 {
+			return InvalidRequestError{Field: "Assure", Reason: "must be positive for type3"}
+		}
+		if r.Max < r.Assure {
+			return InvalidRequestError{Field: "Max", Reason: "must not be lower than Assure"}
+		}
+	case DBAType4:
+		if r.Max <= 0 {
+			return InvalidRequestError{Field: "Max", Reason: "must be positive for type4"}
+		}
+	case DBAType5:
+		if r.Fix <= 0 {
+			return InvalidRequestError{Field: "Fix", Reason: "must be positive for type5"}
+		}
+		if r.Assure < 0 {
+			return InvalidRequestError{Field: "Assure", Reason: "must not be negative"}
+		}
+		if r.Max < r.Fix+r.Assure {
+			return InvalidRequestError{Field: "Max", Reason: "must not be lower than Fix plus Assure"}
+		}
+	default:
+		return InvalidRequestError{Field: "Type", Reason: fmt.Sprintf("unsupported value %d", r.Type)}
+	}
+
+	return nil
+}
+
+func (r DBAProfileRequest) bandwidth() string {
+	switch r.Type {
+	case DBAType1:
+		return fmt.Sprintf("type1 fix %d", r.Fix)
+	case DBAType2:
+		return fmt.Sprintf("type2 assure %d", r.Assure)
+	case DBAType3:
+		return fmt.Sprintf("type3 assure %d max %d", r.Assure, r.Max)
+	case DBAType4:
+		return fmt.Sprintf("type4 max %d", r.Max)
+	default:
+		return fmt.Sprintf("type5 fix %d assure %d max %d", r.Fix, r.Assure, r.Max)
+	}
+}
+
+func (r DBAProfileRequest) command() (string, error) {
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+
+	parts := []string{"dba-profile add"}
+	if r.ProfileID != nil {
+		parts = append(parts, "profile-id", fmt.Sprint(*r.ProfileID))
+	}
+	if r.ProfileName != "" {
+		parts = append(parts, "profile-name", quote(r.ProfileName))
+	}
+	parts = append(parts, r.bandwidth())
+
+	return strings.Join(parts, " "), nil
+}
+
+func (r DBAProfileRequest) modifyCommand() (string, error) {
+	if r.ProfileID == nil {
+		return "", InvalidRequestError{Field: "ProfileID", Reason: "is required to modify a DBA profile"}
+	}
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("dba-profile modify profile-id %d %s", *r.ProfileID, r.bandwidth()), nil
+}
+
+func (c *CommandExecutor) GetDBAProfiles() ([]DBAProfile, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand("display dba-profile all", "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseDBAProfiles(output)
+}
+
+func (c *CommandExecutor) GetDBAProfile(id int) (*DBAProfile, error) {
+	profile, err := c.getDBAProfile(fmt.Sprintf("display dba-profile profile-id %d", id))
+	if err != nil {
+		return nil, err
+	}
+	if profile.ID != id {
+		return nil, ProfileNotFoundError{}
+	}
+	return profile, nil
+}
+
+func (c *CommandExecutor) GetDBAProfileByName(name string) (*DBAProfile, error) {
+	return c.getDBAProfile(fmt.Sprintf("display dba-profile profile-name %s", quote(name)))
+}
+
+func (c *CommandExecutor) getDBAProfile(command string) (*DBAProfile, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(command, "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+
+	profiles, err := ParseDBAProfiles(output)
+	if err != nil {
+		return nil, err
+	}
+	if len(profiles) != 1 {
+		return nil, ProfileNotFoundError{}
+	}
+	return &profiles[0], nil
+}
+
+// CreateDBAProfile returns the ID of the new profile. When req.ProfileID is
+// nil the OLT assigns it and the profile is looked up by name.
+func (c *CommandExecutor) CreateDBAProfile(req DBAProfileRequest) (int, error) {
+	if c.ExecutorContext.Level != 2 {
+		return 0, fmt.Errorf("not in config mode")
+	}
+
+	command, err := req.command()
+	if err != nil {
+		return 0, err
+	}
+
+	output, err := c.ExecuteCommand(command, "(config)#")
+	if err != nil {
+		return 0, fmt.Errorf("failed to run command: %v", err)
+	}
+
+	err = parseLinesFailure(strings.Split(output, "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	if req.ProfileID != nil {
+		return *req.ProfileID, nil
+	}
+	profile, err := c.GetDBAProfileByName(req.ProfileName)
+	if err != nil {
+		return 0, err
+	}
+	return profile.ID, nil
+}
+
+func (c *CommandExecutor) ModifyDBAProfile(req DBAProfileRequest) error {
+	if c.ExecutorContext.Level != 2 {
+		return fmt.Errorf("not in config mode")
+	}
+
+	command, err := req.modifyCommand()
+	if err != nil {
+		return err
+	}
+
+	output, err := c.ExecuteCommand(command, "(config)#")
+	if err != nil {
+		return fmt.Errorf("failed to run command: %v", err)
+	}
+	return parseLinesFailure(strings.Split(output, "\n"))
+}
+
+func (c *CommandExecutor) DeleteDBAProfile(id int) error {
+	if c.ExecutorContext.Level != 2 {
+		return fmt.Errorf("not in config mode")
+	}
+
+	output, err := c.ExecuteCommand(fmt.Sprintf("dba-profile delete profile-id %d", id), "(config)#")
+	if err != nil {
+		return fmt.Errorf("failed to run command: %v", err)
+	}
+	return parseLinesFailure(strings.Split(output, "\n"))
+}
+
+// ParseDBAProfiles parses the "display dba-profile" table. Rows are read by
+// position: ID, type, bandwidth compensation, fix, assure, max, and the bind
+// times in the last column.
+func ParseDBAProfiles(output string) ([]DBAProfile, error) {
+	results := make([]DBAProfile, 0)
+	var name string
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "does not exist") {
+			return nil, ProfileNotFoundError{}
+		}
+		err := parseFailure(line)
+		if err != nil {
+			return nil, err
+		}
+
+		if key, value, ok := splitKeyValue(line); ok && key == "profilename" {
+			name = value
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 7 || !isNumber(fields[0]) || !isNumber(fields[1]) {
+			continue
+		}
+
+		results = append(results, DBAProfile{
+			ID:                    parseIntOrZero(fields[0]),
+			Type:                  DBAType(parseIntOrZero(fields[1])),
+			BandwidthCompensation: strings.EqualFold(fields[2], "yes"),
+			Fix:                   parseIntOrZero(fields[3]),
+			Assure:                parseIntOrZero(fields[4]),
+			Max:                   parseIntOrZero(fields[5]),
+			BindTimes:             parseIntOrZero(fields[len(fields)-1]),
+		})
+	}
+
+	if name != "" && len(results) == 1 {
+		results[0].Name = name
+	}
+	return results, nil
+}
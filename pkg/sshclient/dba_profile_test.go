@@ -0,0 +1,91 @@
+package sshclient
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDBAProfiles(t *testing.T) {
+	profiles, err := ParseDBAProfiles(readTestdata(t, "dba_profile.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := DBAProfile{ID: 12, Name: "dba_100M", Type: DBAType3, Assure: 102400, Max: 102400, BindTimes: 2}
+	if len(profiles) != 1 || profiles[0] != expected {
+		t.Fatalf("got %+v, want %+v", profiles, expected)
+	}
+}
+
+func TestCreateDBAProfileLooksUpIDByName(t *testing.T) {
+	command := "dba-profile add profile-name \"dba_100M\" type3 assure 102400 max 102400"
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		command: "(config)#",
+		"display dba-profile profile-name \"dba_100M\"": readTestdata(t, "dba_profile.txt"),
+	})
+
+	id, err := executor.CreateDBAProfile(DBAProfileRequest{ProfileName: "dba_100M", Type: DBAType3, Assure: 102400, Max: 102400})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 12 {
+		t.Fatalf("expected profile 12, got %d", id)
+	}
+	assertCommands(t, terminal, command, "display dba-profile profile-name \"dba_100M\"")
+}
+
+func TestDBAProfileRequestRequiresIDOrName(t *testing.T) {
+	err := DBAProfileRequest{Type: DBAType4, Max: 102400}.Validate()
+	if err == nil {
+		t.Fatal("expected an error without an ID or a name")
+	}
+}
+
+func TestGetDBAProfiles(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display dba-profile all": readTestdata(t, "dba_profile_all.txt"),
+	})
+
+	profiles, err := executor.GetDBAProfiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display dba-profile all")
+
+	expected := []DBAProfile{
+		{ID: 1, Type: DBAType1, Fix: 5120},
+		{ID: 2, Type: DBAType1, BandwidthCompensation: true, Fix: 1024, BindTimes: 3},
+		{ID: 10, Type: DBAType4, Max: 1024000, BindTimes: 15},
+		{ID: 12, Type: DBAType3, Assure: 102400, Max: 102400, BindTimes: 2},
+		{ID: 20, Type: DBAType5, Fix: 1024, Assure: 32768, Max: 65536},
+	}
+	if len(profiles) != len(expected) {
+		t.Fatalf("expected %d profiles, got %d", len(expected), len(profiles))
+	}
+	for i := range expected {
+		if profiles[i] != expected[i] {
+			t.Fatalf("got %+v, want %+v", profiles[i], expected[i])
+		}
+	}
+}
+
+func TestGetDBAProfileChecksID(t *testing.T) {
+	executor, _ := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display dba-profile profile-id 12": readTestdata(t, "dba_profile_id.txt"),
+		"display dba-profile profile-id 13": strings.Replace(readTestdata(t, "dba_profile_id.txt"), "profile-id 12", "profile-id 13", 1),
+		"display dba-profile profile-id 99": "  Failure: The profile does not exist\n(config)#",
+	})
+
+	profile, err := executor.GetDBAProfile(12)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.ID != 12 || profile.Type != DBAType3 || profile.Max != 102400 {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+
+	for _, id := range []int{13, 99} {
+		if _, err := executor.GetDBAProfile(id); err != (ProfileNotFoundError{}) {
+			t.Fatalf("profile %d: expected ProfileNotFoundError, got %v", id, err)
+		}
+	}
+}
@@ -11,3 +11,30 @@ type InvalidSerialNumberError struct{}
 func (i InvalidSerialNumberError) Error() string {
 	return "Invalid serial number"
 }
+
+type InvalidMACAddressError struct{}
+
+func (i InvalidMACAddressError) Error() string {
+	return "Invalid MAC address"
+}
+
+type InvalidRequestError struct {
+	Field  string
+	Reason string
+}
+
+func (i InvalidRequestError) Error() string {
+	return "Invalid " + i.Field + ": " + i.Reason
+}
+
+type ProfileNotFoundError struct{}
+
+func (p ProfileNotFoundError) Error() string {
+	return "Profile not found"
+}
+
+type TrafficTableNotFoundError struct{}
+
+func (t TrafficTableNotFoundError) Error() string {
+	return "Traffic table not found"
+}
@@ -0,0 +1,505 @@
+package sshclient
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeTerminal stands in for the OLT shell. Every command written to it is
+// recorded and answered with the next queued reply, or else the matching
+// reply. A bare newline answers the pager and is answered with the next page.
+type fakeTerminal struct {
+	replies  map[string]string
+	queued   map[string][]string
+	pages    []string
+	newlines int
+	commands []string
+	output   bytes.Buffer
+}
+
+func (f *fakeTerminal) Write(p []byte) (int, error) {
+	command := strings.TrimSuffix(string(p), "\n")
+	if command == "" {
+		f.newlines++
+		if len(f.pages) > 0 {
+			f.output.WriteString(f.pages[0])
+			f.pages = f.pages[1:]
+		}
+	} else {
+		f.commands = append(f.commands, command)
+		if queue := f.queued[command]; len(queue) > 0 {
+			f.output.WriteString(queue[0])
+			f.queued[command] = queue[1:]
+		} else {
+			f.output.WriteString(f.replies[command])
+		}
+	}
+	return len(p), nil
+}
+
+func (f *fakeTerminal) Read(p []byte) (int, error) {
+	return f.output.Read(p)
+}
+
+func (f *fakeTerminal) Close() error {
+	return nil
+}
+
+func newTestExecutor(context ExecutorContext, replies map[string]string) (*CommandExecutor, *fakeTerminal) {
+	terminal := &fakeTerminal{replies: replies}
+	return &CommandExecutor{
+		Stdout:          terminal,
+		Stdin:           terminal,
+		ExecutorContext: context,
+	}, terminal
+}
+
+func assertCommands(t *testing.T, terminal *fakeTerminal, expected ...string) {
+	t.Helper()
+	if strings.Join(terminal.commands, "\n") != strings.Join(expected, "\n") {
+		t.Fatalf("unexpected commands:\n got: %q\nwant: %q", terminal.commands, expected)
+	}
+}
+
+func TestAddOpticalNetworkTerminalLegacyCommand(t *testing.T) {
+	command := "ont add 3 sn-auth 485754430ABCDEF1 omci ont-lineprofile-id 60 ont-srvprofile-id 35 desc customer name with spaces and more than sixty-four characters in it"
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 3, Frame: 0, Slot: 1}, map[string]string{
+		command: "  Number of ONTs that can be added: 1, success: 1\r\n  PortID :3, ONTID :7\r\n(config-if-gpon-0/1)#",
+	})
+
+	ontID, err := executor.AddOpticalNetworkTerminal(3, "485754430ABCDEF1 (HWTC-0ABCDEF1)", "customer name with spaces and more than sixty-four characters in it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ontID != 7 {
+		t.Fatalf("expected ONT 7, got %d", ontID)
+	}
+	assertCommands(t, terminal, command)
+}
+
+func TestAddServicePortLegacyCommand(t *testing.T) {
+	command := "service-port vlan 100 gpon 0/1/3 ont 7 gemport 20 multi-service user-vlan 20 tag-transform translate inbound traffic-table index 10 outbound traffic-table index 10"
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{command: "(config)#"})
+
+	err := executor.AddServicePort(100, 0, 1, 3, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, command)
+}
+
+func TestAddServicePortWithSpecReturnsNewIndex(t *testing.T) {
+	command := "service-port vlan 200 gpon 0/1/3 ont 7 gemport 21 multi-service user-vlan 30 tag-transform translate inbound traffic-table index 10 outbound traffic-table index 10"
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{command: "(config)#"})
+	terminal.queued = map[string][]string{
+		"display service-port port 0/1/3 ont 7": {readTestdata(t, "service_port_before.txt"), readTestdata(t, "service_port_after.txt")},
+	}
+
+	trafficTable := 10
+	index, err := executor.AddServicePortWithSpec(ServicePortSpec{
+		Vlan:                      200,
+		Frame:                     0,
+		Slot:                      1,
+		Port:                      3,
+		ONTID:                     7,
+		GemPort:                   21,
+		UserVlan:                  30,
+		InboundTrafficTableIndex:  &trafficTable,
+		OutboundTrafficTableIndex: &trafficTable,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 13 {
+		t.Fatalf("expected index 13, got %d", index)
+	}
+	assertCommands(t, terminal, "display service-port port 0/1/3 ont 7", command, "display service-port port 0/1/3 ont 7")
+}
+
+func TestAddServicePortWithSpecExplicitIndex(t *testing.T) {
+	command := "service-port 40 vlan 100 gpon 0/1/3 ont 7 gemport 20 multi-service user-vlan untagged tag-transform default inbound traffic-table name \"ftth\" outbound traffic-table name \"ftth\""
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{command: "(config)#"})
+
+	index := 40
+	created, err := executor.AddServicePortWithSpec(ServicePortSpec{
+		Index:                    &index,
+		Vlan:                     100,
+		Frame:                    0,
+		Slot:                     1,
+		Port:                     3,
+		ONTID:                    7,
+		GemPort:                  20,
+		UserVlanMode:             UserVlanUntagged,
+		TagTransform:             TagTransformDefault,
+		InboundTrafficTableName:  "ftth",
+		OutboundTrafficTableName: "ftth",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if created != 40 {
+		t.Fatalf("expected index 40, got %d", created)
+	}
+	assertCommands(t, terminal, command)
+}
+
+func TestGetONTPortNativeVlansIncludesIPHost(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 3, Frame: 0, Slot: 1}, map[string]string{
+		"display ont port attribute 0 1 eth": readTestdata(t, "ont_port_attribute_eth.txt"),
+		"display ont ipconfig 0 1":           readTestdata(t, "ont_ipconfig.txt"),
+	})
+
+	nativeVlans, err := executor.GetONTPortNativeVlans(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display ont port attribute 0 1 eth", "display ont ipconfig 0 1")
+
+	if len(nativeVlans) != 6 {
+		t.Fatalf("expected 6 native VLANs, got %d: %+v", len(nativeVlans), nativeVlans)
+	}
+	expected := ONTPortNativeVlan{ONTID: 1, PortID: 0, PortType: "iphost", NativeVlan: 20, Priority: 5}
+	if nativeVlans[4] != expected {
+		t.Fatalf("got %+v, want %+v", nativeVlans[4], expected)
+	}
+}
+
+func TestGetBoardOpticalReadingsMA5600(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 3, Frame: 0, Slot: 1}, map[string]string{
+		"display version":                readTestdata(t, "display_version.txt"),
+		"display board 0/1":              readTestdata(t, "display_board_gpon.txt"),
+		"display ont optical-info 0 all": readTestdata(t, "ont_optical_info_port.txt"),
+		"display ont optical-info 1 all": readTestdata(t, "ont_optical_info_port.txt"),
+	})
+
+	readings, err := executor.GetBoardOpticalReadings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display version", "display board 0/1", "display ont optical-info 0 all", "display ont optical-info 1 all")
+	if len(readings) != 6 || readings[0].FSP != "0/1/0" || readings[3].FSP != "0/1/1" {
+		t.Fatalf("unexpected readings %+v", readings)
+	}
+}
+
+func TestGetBoardOpticalReadingsMA5800(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 3, Frame: 0, Slot: 1}, map[string]string{
+		"display version":              readTestdata(t, "display_version_ma5800.txt"),
+		"display ont optical-info all": readTestdata(t, "ont_optical_info_board.txt"),
+	})
+
+	readings, err := executor.GetBoardOpticalReadings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display version", "display ont optical-info all")
+	if len(readings) != 3 {
+		t.Fatalf("expected 3 readings, got %d", len(readings))
+	}
+}
+
+func TestGetBoardONTVersionsMA5600(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display version":               readTestdata(t, "display_version.txt"),
+		"display board 0/1":             readTestdata(t, "display_board_gpon.txt"),
+		"display ont version 0 1 0 all": readTestdata(t, "ont_version.txt"),
+		"display ont version 0 1 1 all": "  The required ONT does not exist\n(config)#",
+	})
+
+	versions, err := executor.GetBoardONTVersions(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display version", "display board 0/1", "display ont version 0 1 0 all", "display ont version 0 1 1 all")
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+}
+
+func TestGetBoardONTVersionsMA5800(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display version":             readTestdata(t, "display_version_ma5800.txt"),
+		"display ont version 0 1 all": readTestdata(t, "ont_version.txt"),
+	})
+
+	versions, err := executor.GetBoardONTVersions(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display version", "display ont version 0 1 all")
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+}
+
+func TestGetONTOpticalInfoSwitchesBoard(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 3, Frame: 0, Slot: 2}, map[string]string{
+		"quit":                         "(config)#",
+		"interface gpon 0/1":           "(config-if-gpon-0/1)#",
+		"interface gpon 0/2":           "(config-if-gpon-0/2)#",
+		"display ont optical-info 3 7": readTestdata(t, "ont_optical_info.txt"),
+	})
+
+	info, err := executor.GetONTOpticalInfo(0, 1, 3, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.RxOpticalPower == "" {
+		t.Fatalf("expected optical readings, got %+v", info)
+	}
+	assertCommands(t, terminal, "quit", "interface gpon 0/1", "display ont optical-info 3 7", "quit", "interface gpon 0/2")
+	if executor.ExecutorContext != (ExecutorContext{Level: 3, Frame: 0, Slot: 2}) {
+		t.Fatalf("expected to be back on board 0/2, got %+v", executor.ExecutorContext)
+	}
+}
+
+func TestGetPortOpticalInfo(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"interface gpon 0/1":             "(config-if-gpon-0/1)#",
+		"display ont optical-info 3 all": readTestdata(t, "ont_optical_info_port.txt"),
+		"quit":                           "(config)#",
+	})
+
+	infos, err := executor.GetPortOpticalInfo(0, 1, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "interface gpon 0/1", "display ont optical-info 3 all", "quit")
+	if len(infos) != 3 || infos[1].RxOpticalPower != "-" {
+		t.Fatalf("unexpected optical info %+v", infos)
+	}
+	metrics := infos[2].Metrics()
+	if *metrics.RxOpticalPower != -19.02 || *metrics.Voltage != 3.3 || *metrics.OLTRxONTOpticalPower != -22.1 {
+		t.Fatalf("unexpected metrics %+v", metrics)
+	}
+}
+
+func TestGetPortGeneralInfo(t *testing.T) {
+	executor, _ := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display ont info summary 0/1/0": readTestdata(t, "ont_info_summary.txt"),
+	})
+
+	infos, err := executor.GetPortGeneralInfo(0, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := GeneralInfo{
+		FSP:          "0/1/0",
+		ID:           "1",
+		RunState:     "offline",
+		Distance:     "-",
+		SN:           "485754430ABCDEF2",
+		Description:  "customer 2",
+		LatDownCause: "LOSi/LOBi",
+		LastUpTime:   "-",
+		LastDownTime: "2023-05-02 08:00:00",
+	}
+	if len(infos) != 2 || *infos[1] != expected {
+		t.Fatalf("got %+v, want %+v", infos[1], expected)
+	}
+}
+
+func TestGetONTEthernetPortStates(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 3, Frame: 0, Slot: 1}, map[string]string{
+		"display ont port state 0 1 eth-port all": readTestdata(t, "ont_port_state_1port.txt"),
+		"display ont port state 0 2 eth-port all": readTestdata(t, "ont_port_state_4port.txt"),
+	})
+
+	gigabit, fast := 1000, 100
+	tests := []struct {
+		ontID    int
+		expected []ONTEthernetPortState
+	}{
+		{1, []ONTEthernetPortState{
+			{ONTID: 1, PortID: 1, PortType: "GE", Speed: &gigabit, Duplex: "full", LinkState: "up", LinkUp: true, RingStatus: "normal"},
+		}},
+		{2, []ONTEthernetPortState{
+			{ONTID: 2, PortID: 1, PortType: "GE", Speed: &gigabit, Duplex: "full", LinkState: "up", LinkUp: true, RingStatus: "normal"},
+			{ONTID: 2, PortID: 2, PortType: "GE", Speed: &fast, Duplex: "half", LinkState: "up", LinkUp: true, RingStatus: "normal"},
+			{ONTID: 2, PortID: 3, PortType: "FE", Duplex: "-", LinkState: "down", RingStatus: "normal"},
+			{ONTID: 2, PortID: 4, PortType: "FE", Duplex: "-", LinkState: "down", RingStatus: "-"},
+		}},
+	}
+
+	for _, test := range tests {
+		states, err := executor.GetONTEthernetPortStates(0, test.ontID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(states) != len(test.expected) {
+			t.Fatalf("ONT %d: expected %d ports, got %d", test.ontID, len(test.expected), len(states))
+		}
+		for i, expected := range test.expected {
+			state := states[i]
+			if (state.Speed == nil) != (expected.Speed == nil) || (state.Speed != nil && *state.Speed != *expected.Speed) {
+				t.Fatalf("ONT %d port %d: unexpected speed %v", test.ontID, expected.PortID, state.Speed)
+			}
+			state.Speed, expected.Speed = nil, nil
+			if state != expected {
+				t.Fatalf("ONT %d: got %+v, want %+v", test.ontID, state, expected)
+			}
+		}
+	}
+	assertCommands(t, terminal, "display ont port state 0 1 eth-port all", "display ont port state 0 2 eth-port all")
+}
+
+const (
+	servicePortPager = "---- More ( Press 'Q' to break ) ----"
+	servicePortPage1 = `display service-port all
+  Switch-Oriented Flow List
+  -----------------------------------------------------------------------------
+   INDEX VLAN VLAN     PORT F/ S/ P VPI  VCI   FLOW  FLOW       RX   TX   STATE
+         ID   ATTR     TYPE                    TYPE  PARA
+  -----------------------------------------------------------------------------
+      12  100 common   gpon 0/1 /3  7    20    vlan  20         10   10   up
+      13  200 common   gpon 0/1 /3  7    21    vlan  30         10   10   down
+` + servicePortPager
+	servicePortPage2 = "\x1b[37D                                     \x1b[37D" + `      14  100 common   gpon 0/1 /4  2    20    vlan  20         10   10   up
+      15  300 common   gpon 0/2 /0  1    22    vlan  40         10   10   up
+  -----------------------------------------------------------------------------
+   Total : 4  (Up/Down :    3/1)
+
+(config)#`
+)
+
+func TestStreamAllServicePortsPaged(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{"display service-port all": servicePortPage1})
+	terminal.pages = []string{servicePortPage2}
+
+	indexes := make([]int, 0)
+	err := executor.StreamAllServicePorts(func(servicePort ServicePort) error {
+		indexes = append(indexes, servicePort.Index)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexes) != 4 || indexes[0] != 12 || indexes[1] != 13 || indexes[2] != 14 || indexes[3] != 15 {
+		t.Fatalf("expected service ports 12 to 15 in order, got %v", indexes)
+	}
+	if terminal.newlines != 1 || len(terminal.pages) != 0 {
+		t.Fatalf("expected the pager to be answered once, got %d answers and %d pages left", terminal.newlines, len(terminal.pages))
+	}
+	assertCommands(t, terminal, "display service-port all")
+}
+
+func TestStreamAllServicePortsStopsOnCallbackError(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display service-port all": servicePortPage1,
+		"display sysuptime":        "  System up time: 1 day(s)\n(config)#",
+	})
+	terminal.pages = []string{servicePortPage2}
+
+	stop := errors.New("stop")
+	calls := 0
+	err := executor.StreamAllServicePorts(func(ServicePort) error {
+		calls++
+		return stop
+	})
+	if err != stop {
+		t.Fatalf("expected the callback error, got %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("expected the stream to stop after the first row, got %d calls", calls)
+	}
+	if terminal.output.Len() != 0 || len(terminal.pages) != 0 {
+		t.Fatalf("expected the output to be drained, %d bytes and %d pages left", terminal.output.Len(), len(terminal.pages))
+	}
+
+	output, err := executor.ExecuteCommand("display sysuptime", "(config)#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(output), "System up time") {
+		t.Fatalf("expected only the next command output, got %q", output)
+	}
+}
+
+func TestStreamOutputFiltersUnsolicitedMessages(t *testing.T) {
+	page := strings.Replace(servicePortPage2, "      15 ", unsolicitedAlarm+"      15 ", 1)
+
+	for _, filter := range []bool{false, true} {
+		executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{"display service-port all": servicePortPage1})
+		terminal.pages = []string{page}
+		executor.FilterUnsolicited = filter
+
+		lines := make([]string, 0)
+		if err := executor.write("display service-port all\n"); err != nil {
+			t.Fatal(err)
+		}
+		err := executor.streamOutputUntilPrompt("(config)#", func(line string) error {
+			lines = append(lines, line)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		output := strings.Join(lines, "\n")
+		if strings.Contains(output, "ALARM") == filter {
+			t.Fatalf("filter %v: unexpected output %q", filter, output)
+		}
+		if !strings.Contains(output, "      15  300") || !strings.HasSuffix(output, "(config)#") {
+			t.Fatalf("filter %v: expected the rows after the alarm and the prompt, got %q", filter, output)
+		}
+	}
+}
+
+func TestGetGeneralInfoByDescription(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		`display ont info by-desc "branch office"`: readTestdata(t, "ont_info_by_desc.txt"),
+	})
+
+	infos, err := executor.GetGeneralInfoByDescription("branch office")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, `display ont info by-desc "branch office"`)
+
+	if len(infos) != 2 {
+		t.Fatalf("expected 2 ONTs, got %d", len(infos))
+	}
+	expected := [][4]string{
+		{"0/1/3", "7", "online", "485754430ABCDEF2 (HWTC-0ABCDEF2)"},
+		{"0/2/0", "1", "offline", "485754430ABCDEF3 (HWTC-0ABCDEF3)"},
+	}
+	for i, info := range infos {
+		got := [4]string{info.FSP, info.ID, info.RunState, info.SN}
+		if got != expected[i] || info.Description != "branch office" || info.LatDownCause != "dying-gasp" {
+			t.Fatalf("ONT %d: unexpected info %+v", i, info)
+		}
+	}
+}
+
+func TestGetGeneralInfoByDescriptionNoMatch(t *testing.T) {
+	executor, _ := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		`display ont info by-desc "nobody"`: "  The required ONT does not exist\n(config)#",
+	})
+
+	infos, err := executor.GetGeneralInfoByDescription("nobody")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(infos) != 0 {
+		t.Fatalf("expected no ONTs, got %+v", infos)
+	}
+}
+
+func TestGetGeneralInfoByMAC(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display location 00e0-fc12-3457": readTestdata(t, "location.txt"),
+		"display ont info 0 1 3 7":        readTestdata(t, "ont_info_0_1_3_7.txt"),
+	})
+
+	info, err := executor.GetGeneralInfoByMAC("00-E0-FC-12-34-57")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display location 00e0-fc12-3457", "display ont info 0 1 3 7")
+
+	if info.FSP != "0/1/3" || info.ID != "7" || info.Description != "customer 2" {
+		t.Fatalf("unexpected info %+v", info)
+	}
+}
@@ -0,0 +1,187 @@
+package sshclient
+
+import "fmt"
+
+type HealthStatus string
+
+const (
+	HealthUnknown HealthStatus = "unknown"
+	HealthOK      HealthStatus = "ok"
+	HealthWarning HealthStatus = "warning"
+	HealthAlarm   HealthStatus = "alarm"
+)
+
+func (h HealthStatus) severity() int {
+	switch h {
+	case HealthOK:
+		return 1
+	case HealthWarning:
+		return 2
+	case HealthAlarm:
+		return 3
+	default:
+		return 0
+	}
+}
+
+type OpticalMetric string
+
+const (
+	OpticalMetricRxPower     OpticalMetric = "rx_power"
+	OpticalMetricTxPower     OpticalMetric = "tx_power"
+	OpticalMetricBiasCurrent OpticalMetric = "bias_current"
+	OpticalMetricTemperature OpticalMetric = "temperature"
+	OpticalMetricVoltage     OpticalMetric = "voltage"
+	OpticalMetricOLTRxPower  OpticalMetric = "olt_rx_power"
+)
+
+// OpticalPolicy is a team-defined rule such as "Rx below -27 dBm is
+// degraded". It matches when the metric is below Below or above Above and
+// can only make the status reported by the OLT thresholds worse.
+type OpticalPolicy struct {
+	Name   string
+	Metric OpticalMetric
+	Below  *float64
+	Above  *float64
+	Status HealthStatus
+}
+
+type MetricHealth struct {
+	Metric OpticalMetric `json:"metric"`
+	Value  *float64      `json:"value"`
+	Status HealthStatus  `json:"status"`
+	Reason string        `json:"reason,omitempty"`
+}
+
+type OpticalHealth struct {
+	Status  HealthStatus   `json:"status"`
+	Metrics []MetricHealth `json:"metrics"`
+}
+
+func (h OpticalHealth) Metric(metric OpticalMetric) (MetricHealth, bool) {
+	for _, m := range h.Metrics {
+		if m.Metric == metric {
+			return m, true
+		}
+	}
+	return MetricHealth{}, false
+}
+
+// OpticalEvaluator classifies OpticalInfo readings against the thresholds the
+// ONT reports. The OLT does not report thresholds for the upstream direction,
+// so OLTRxONTOpticalPower is checked against OLTRxWarningThreshold and
+// OLTRxAlarmThreshold instead.
+type OpticalEvaluator struct {
+	OLTRxWarningThreshold Threshold
+	OLTRxAlarmThreshold   Threshold
+	Policies              []OpticalPolicy
+}
+
+// Sensitivity and overload of GPON OLT receivers in dBm, per ITU-T G.984.2.
+const (
+	ClassBPlusOLTRxSensitivity = -28.0
+	ClassBPlusOLTRxOverload    = -8.0
+	ClassCPlusOLTRxSensitivity = -32.0
+	ClassCPlusOLTRxOverload    = -12.0
+)
+
+// OLTRxWarningMargin is how far inside the receiver limits the upstream
+// warning threshold lies, in dB.
+const OLTRxWarningMargin = 1.0
+
+// NewOpticalEvaluator returns an evaluator for a class B+ OLT receiver.
+func NewOpticalEvaluator(policies ...OpticalPolicy) *OpticalEvaluator {
+	return NewOpticalEvaluatorForReceiver(ClassBPlusOLTRxSensitivity, ClassBPlusOLTRxOverload, policies...)
+}
+
+func NewOpticalEvaluatorForReceiver(sensitivity, overload float64, policies ...OpticalPolicy) *OpticalEvaluator {
+	return &OpticalEvaluator{
+		OLTRxWarningThreshold: Threshold{Min: float64Pointer(sensitivity + OLTRxWarningMargin), Max: float64Pointer(overload - OLTRxWarningMargin)},
+		OLTRxAlarmThreshold:   Threshold{Min: float64Pointer(sensitivity), Max: float64Pointer(overload)},
+		Policies:              policies,
+	}
+}
+
+func (e *OpticalEvaluator) Evaluate(info *OpticalInfo) OpticalHealth {
+	metrics := info.Metrics()
+
+	results := []MetricHealth{
+		evaluateThresholds(OpticalMetricRxPower, metrics.RxOpticalPower, metrics.RxPowerCurrentWarningThreshold, metrics.RxPowerCurrentAlarmThreshold),
+		evaluateThresholds(OpticalMetricTxPower, metrics.TxOpticalPower, metrics.TxPowerCurrentWarningThreshold, metrics.TxPowerCurrentAlarmThreshold),
+		evaluateThresholds(OpticalMetricBiasCurrent, metrics.LaserBiasCurrent, metrics.TxBiasCurrentWarningThreshold, metrics.TxBiasCurrentAlarmThreshold),
+		evaluateThresholds(OpticalMetricTemperature, metrics.Temperature, metrics.TemperatureWarningThreshold, metrics.TemperatureAlarmThreshold),
+		evaluateThresholds(OpticalMetricVoltage, metrics.Voltage, metrics.SupplyVoltageWarningThreshold, metrics.SupplyVoltageAlarmThreshold),
+		evaluateThresholds(OpticalMetricOLTRxPower, metrics.OLTRxONTOpticalPower, e.OLTRxWarningThreshold, e.OLTRxAlarmThreshold),
+	}
+
+	health := OpticalHealth{Status: HealthUnknown}
+	for i := range results {
+		for _, policy := range e.Policies {
+			applyPolicy(&results[i], policy)
+		}
+		if results[i].Status.severity() > health.Status.severity() {
+			health.Status = results[i].Status
+		}
+	}
+	health.Metrics = results
+
+	return health
+}
+
+func EvaluateOpticalHealth(info *OpticalInfo, policies ...OpticalPolicy) OpticalHealth {
+	return NewOpticalEvaluator(policies...).Evaluate(info)
+}
+
+func evaluateThresholds(metric OpticalMetric, value *float64, warning, alarm Threshold) MetricHealth {
+	result := MetricHealth{Metric: metric, Value: value, Status: HealthUnknown}
+	if value == nil {
+		return result
+	}
+
+	if outside, bound := outsideThreshold(*value, alarm); outside {
+		result.Status = HealthAlarm
+		result.Reason = fmt.Sprintf("outside alarm threshold (%s)", bound)
+		return result
+	}
+	if outside, bound := outsideThreshold(*value, warning); outside {
+		result.Status = HealthWarning
+		result.Reason = fmt.Sprintf("outside warning threshold (%s)", bound)
+		return result
+	}
+
+	if hasBounds(alarm) || hasBounds(warning) {
+		result.Status = HealthOK
+	}
+	return result
+}
+
+func applyPolicy(result *MetricHealth, policy OpticalPolicy) {
+	if policy.Metric != result.Metric || result.Value == nil {
+		return
+	}
+
+	matched := (policy.Below != nil && *result.Value < *policy.Below) ||
+		(policy.Above != nil && *result.Value > *policy.Above)
+	if matched && policy.Status.severity() > result.Status.severity() {
+		result.Status = policy.Status
+		result.Reason = fmt.Sprintf("matched policy %q", policy.Name)
+	}
+}
+
+func outsideThreshold(value float64, threshold Threshold) (bool, string) {
+	if threshold.Min != nil && value < *threshold.Min {
+		return true, fmt.Sprintf("below %.2f", *threshold.Min)
+	}
+	if threshold.Max != nil && value > *threshold.Max {
+		return true, fmt.Sprintf("above %.2f", *threshold.Max)
+	}
+	return false, ""
+}
+
+func hasBounds(threshold Threshold) bool {
+	return threshold.Min != nil || threshold.Max != nil
+}
+
+func float64Pointer(value float64) *float64 {
+	return &value
+}
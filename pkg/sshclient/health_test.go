@@ -0,0 +1,73 @@
+package sshclient
+
+import "testing"
+
+func readOpticalInfo(t *testing.T) *OpticalInfo {
+	t.Helper()
+	info, err := ParseOpticalInfo(readTestdata(t, "ont_optical_info.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestEvaluateOpticalHealth(t *testing.T) {
+	health := EvaluateOpticalHealth(readOpticalInfo(t))
+	if health.Status != HealthOK {
+		t.Fatalf("expected ok, got %+v", health)
+	}
+	for _, metric := range health.Metrics {
+		if metric.Status != HealthOK {
+			t.Fatalf("expected %s to be ok, got %+v", metric.Metric, metric)
+		}
+	}
+}
+
+func TestEvaluateOpticalHealthPolicy(t *testing.T) {
+	health := EvaluateOpticalHealth(readOpticalInfo(t), OpticalPolicy{
+		Name:   "degraded",
+		Metric: OpticalMetricRxPower,
+		Below:  float64Pointer(-20),
+		Status: HealthWarning,
+	})
+
+	rx, _ := health.Metric(OpticalMetricRxPower)
+	if rx.Status != HealthWarning || rx.Reason != `matched policy "degraded"` {
+		t.Fatalf("unexpected rx health %+v", rx)
+	}
+	if health.Status != HealthWarning {
+		t.Fatalf("expected warning, got %s", health.Status)
+	}
+}
+
+func TestEvaluateOpticalHealthWithoutThresholds(t *testing.T) {
+	info := &OpticalInfo{Voltage: "3.280"}
+	health := EvaluateOpticalHealth(info, OpticalPolicy{
+		Name:   "low voltage",
+		Metric: OpticalMetricVoltage,
+		Below:  float64Pointer(3.0),
+		Status: HealthAlarm,
+	})
+
+	voltage, _ := health.Metric(OpticalMetricVoltage)
+	if voltage.Status != HealthUnknown {
+		t.Fatalf("expected unknown, got %+v", voltage)
+	}
+	if health.Status != HealthUnknown {
+		t.Fatalf("expected unknown, got %s", health.Status)
+	}
+}
+
+func TestEvaluateOLTRxPowerByReceiverClass(t *testing.T) {
+	info := &OpticalInfo{OLTRxONTOpticalPower: "-27.50"}
+
+	oltRx, _ := NewOpticalEvaluator().Evaluate(info).Metric(OpticalMetricOLTRxPower)
+	if oltRx.Status != HealthWarning {
+		t.Fatalf("expected warning on class B+, got %+v", oltRx)
+	}
+
+	oltRx, _ = NewOpticalEvaluatorForReceiver(ClassCPlusOLTRxSensitivity, ClassCPlusOLTRxOverload).Evaluate(info).Metric(OpticalMetricOLTRxPower)
+	if oltRx.Status != HealthOK {
+		t.Fatalf("expected ok on class C+, got %+v", oltRx)
+	}
+}
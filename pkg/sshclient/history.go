@@ -0,0 +1,178 @@
+package sshclient
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+type DownCause string
+
+const (
+	DownCauseDyingGasp   DownCause = "dying-gasp"
+	DownCauseLOS         DownCause = "LOS"
+	DownCauseLOSi        DownCause = "LOSi"
+	DownCauseLOFi        DownCause = "LOFi"
+	DownCauseLOBi        DownCause = "LOBi"
+	DownCauseSFi         DownCause = "SFi"
+	DownCauseLOAMi       DownCause = "LOAMi"
+	DownCauseLOKi        DownCause = "LOKi"
+	DownCauseDeactivated DownCause = "deactivated"
+	DownCauseRing        DownCause = "ring"
+)
+
+// IsPowerCut reports whether the ONT went down because it lost power.
+func (d DownCause) IsPowerCut() bool {
+	return d.matches(DownCauseDyingGasp)
+}
+
+// IsFibreCut reports whether the ONT went down because the OLT lost its
+// optical signal or frames, which usually points at the fibre.
+func (d DownCause) IsFibreCut() bool {
+	return d.matches(DownCauseLOS, DownCauseLOSi, DownCauseLOFi, DownCauseLOBi)
+}
+
+// matches reports whether any part of a compound cause such as "LOSi/LOBi"
+// is one of causes.
+func (d DownCause) matches(causes ...DownCause) bool {
+	for _, part := range strings.Split(string(d), "/") {
+		for _, cause := range causes {
+			if strings.EqualFold(strings.TrimSpace(part), string(cause)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type ONTRegisterRecord struct {
+	Index     int    `json:"index"`
+	AuthType  string `json:"auth_type"`
+	SN        string `json:"sn"`
+	Type      string `json:"type"`
+	UpTime    string `json:"up_time"`
+	DownTime  string `json:"down_time"`
+	DownCause string `json:"down_cause"`
+}
+
+type ONTEventType string
+
+const (
+	ONTEventUp   ONTEventType = "up"
+	ONTEventDown ONTEventType = "down"
+)
+
+type ONTRegisterEvent struct {
+	Type  ONTEventType `json:"type"`
+	Time  time.Time    `json:"time"`
+	Cause DownCause    `json:"cause,omitempty"`
+}
+
+func (c *CommandExecutor) GetONTRegisterInfo(port, ontID int) ([]ONTRegisterRecord, error) {
+	if c.ExecutorContext.Level != 3 {
+		return nil, fmt.Errorf("not in interface gpon mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont register-info %d %d", port, ontID), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseONTRegisterInfo(output)
+}
+
+// GetONTRegisterHistory returns the up and down events kept in the ONT
+// register info, oldest first.
+func (c *CommandExecutor) GetONTRegisterHistory(port, ontID int) ([]ONTRegisterEvent, error) {
+	records, err := c.GetONTRegisterInfo(port, ontID)
+	if err != nil {
+		return nil, err
+	}
+	return ONTRegisterHistory(records), nil
+}
+
+// ParseONTRegisterInfo parses "display ont register-info P ONTID". Every
+// registration starts with an "Index" line.
+func ParseONTRegisterInfo(output string) ([]ONTRegisterRecord, error) {
+	results := make([]ONTRegisterRecord, 0)
+
+	var record *ONTRegisterRecord
+	for _, line := range strings.Split(output, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "The required ONT does not exist" {
+			return nil, NotFoundError{}
+		}
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		key, value, ok := splitKeyValue(trimmedLine)
+		if !ok {
+			continue
+		}
+
+		if key == "index" {
+			if record != nil {
+				results = append(results, *record)
+			}
+			record = &ONTRegisterRecord{Index: parseIntOrZero(value)}
+			continue
+		}
+		if record == nil {
+			continue
+		}
+
+		switch key {
+		case "authtype":
+			record.AuthType = value
+		case "sn":
+			record.SN = value
+		case "type":
+			record.Type = value
+		case "uptime":
+			record.UpTime = parseDateTime(value)
+		case "downtime":
+			record.DownTime = parseDateTime(value)
+		case "downcause":
+			record.DownCause = value
+		}
+	}
+
+	if record != nil {
+		results = append(results, *record)
+	}
+
+	return results, nil
+}
+
+// ONTRegisterHistory turns register records into up and down events sorted
+// by time. Records whose times the OLT reports as "-" yield no event.
+func ONTRegisterHistory(records []ONTRegisterRecord) []ONTRegisterEvent {
+	events := make([]ONTRegisterEvent, 0, len(records)*2)
+	for _, record := range records {
+		if upTime := parseOptionalTime(record.UpTime); upTime != nil {
+			events = append(events, ONTRegisterEvent{Type: ONTEventUp, Time: *upTime})
+		}
+		if downTime := parseOptionalTime(record.DownTime); downTime != nil {
+			events = append(events, ONTRegisterEvent{Type: ONTEventDown, Time: *downTime, Cause: DownCause(record.DownCause)})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+
+	return events
+}
+
+// CountFlaps returns the number of down events at or after since.
+func CountFlaps(events []ONTRegisterEvent, since time.Time) int {
+	flaps := 0
+	for _, event := range events {
+		if event.Type == ONTEventDown && !event.Time.Before(since) {
+			flaps++
+		}
+	}
+	return flaps
+}
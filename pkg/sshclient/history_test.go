@@ -0,0 +1,97 @@
+package sshclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseONTRegisterInfo(t *testing.T) {
+	records, err := ParseONTRegisterInfo(readTestdata(t, "ont_register_info.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("expected 3 records, got %d", len(records))
+	}
+	expected := ONTRegisterRecord{
+		Index:     2,
+		AuthType:  "SN-auth",
+		SN:        "485754430ABCDEF2 (HWTC-0ABCDEF2)",
+		Type:      "HG8546M",
+		UpTime:    "2023-05-01 18:00:05+08:00",
+		DownTime:  "2023-05-02 08:00:00+08:00",
+		DownCause: "LOSi/LOBi",
+	}
+	if records[1] != expected {
+		t.Fatalf("got %+v, want %+v", records[1], expected)
+	}
+}
+
+func TestParseONTRegisterInfoNotFound(t *testing.T) {
+	_, err := ParseONTRegisterInfo("  Failure: The required ONT does not exist\n")
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	_, err = ParseONTRegisterInfo("  The required ONT does not exist\n")
+	if _, ok := err.(NotFoundError); !ok {
+		t.Fatalf("expected NotFoundError, got %v", err)
+	}
+}
+
+func TestGetONTRegisterHistory(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 3, Frame: 0, Slot: 1}, map[string]string{
+		"display ont register-info 0 1": readTestdata(t, "ont_register_info.txt"),
+	})
+
+	events, err := executor.GetONTRegisterHistory(0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display ont register-info 0 1")
+
+	zone := time.FixedZone("", 8*60*60)
+	expected := []ONTRegisterEvent{
+		{Type: ONTEventUp, Time: time.Date(2023, 4, 28, 7, 12, 40, 0, zone)},
+		{Type: ONTEventDown, Time: time.Date(2023, 5, 1, 17, 58, 21, 0, zone), Cause: DownCauseDyingGasp},
+		{Type: ONTEventUp, Time: time.Date(2023, 5, 1, 18, 0, 5, 0, zone)},
+		{Type: ONTEventDown, Time: time.Date(2023, 5, 2, 8, 0, 0, 0, zone), Cause: "LOSi/LOBi"},
+		{Type: ONTEventUp, Time: time.Date(2023, 5, 2, 9, 30, 0, 0, zone)},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %+v", len(expected), events)
+	}
+	for i := range expected {
+		if events[i].Type != expected[i].Type || !events[i].Time.Equal(expected[i].Time) || events[i].Cause != expected[i].Cause {
+			t.Fatalf("event %d: got %+v, want %+v", i, events[i], expected[i])
+		}
+	}
+
+	if flaps := CountFlaps(events, time.Date(2023, 5, 1, 0, 0, 0, 0, zone)); flaps != 2 {
+		t.Fatalf("expected 2 flaps since May 1, got %d", flaps)
+	}
+	if flaps := CountFlaps(events, time.Date(2023, 5, 2, 8, 0, 0, 0, zone)); flaps != 1 {
+		t.Fatalf("expected 1 flap at the last down time, got %d", flaps)
+	}
+}
+
+func TestDownCauseClassification(t *testing.T) {
+	tests := []struct {
+		cause    DownCause
+		powerCut bool
+		fibreCut bool
+	}{
+		{DownCauseDyingGasp, true, false},
+		{"DYING-GASP", true, false},
+		{DownCauseLOSi, false, true},
+		{"LOSi/LOBi", false, true},
+		{"LOFi/LOBi", false, true},
+		{DownCauseDeactivated, false, false},
+		{DownCauseLOAMi, false, false},
+		{"-", false, false},
+	}
+	for _, test := range tests {
+		if test.cause.IsPowerCut() != test.powerCut || test.cause.IsFibreCut() != test.fibreCut {
+			t.Errorf("%q: got power cut %v and fibre cut %v", test.cause, test.cause.IsPowerCut(), test.cause.IsFibreCut())
+		}
+	}
+}
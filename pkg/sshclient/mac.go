@@ -0,0 +1,142 @@
+package sshclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+type MACAddressEntry struct {
+	ServicePortIndex int    `json:"service_port_index"`
+	PortType         string `json:"port_type"`
+	MAC              string `json:"mac"`
+	LearnType        string `json:"learn_type"`
+	FSP              string `json:"fsp"`
+	ONTID            int    `json:"ont_id"`
+	GemPort          int    `json:"gem_port"`
+	Vlan             int    `json:"vlan"`
+}
+
+func (m *MACAddressEntry) GetFrameSlotPort() (int, int, int, error) {
+	return getFrameSlotPortFromFSP(m.FSP)
+}
+
+func (c *CommandExecutor) GetMACAddressesByPort(frame, slot, port int) ([]MACAddressEntry, error) {
+	return c.getMACAddresses(fmt.Sprintf("display mac-address port %d/%d/%d", frame, slot, port))
+}
+
+func (c *CommandExecutor) GetMACAddressesByONT(frame, slot, port, ontID int) ([]MACAddressEntry, error) {
+	return c.getMACAddresses(fmt.Sprintf("display mac-address ont %d/%d/%d %d", frame, slot, port, ontID))
+}
+
+func (c *CommandExecutor) GetMACAddressesByServicePort(index int) ([]MACAddressEntry, error) {
+	return c.getMACAddresses(fmt.Sprintf("display mac-address service-port %d", index))
+}
+
+func (c *CommandExecutor) GetMACAddressesByVlan(vlan int) ([]MACAddressEntry, error) {
+	return c.getMACAddresses(fmt.Sprintf("display mac-address vlan %d", vlan))
+}
+
+func (c *CommandExecutor) GetMACLocation(mac string) (*MACAddressEntry, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	huaweiFormat, err := huaweiMAC(mac)
+	if err != nil {
+		return nil, err
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display location %s", huaweiFormat), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseMACLocation(output)
+}
+
+func (c *CommandExecutor) getMACAddresses(command string) ([]MACAddressEntry, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(command, "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseMACAddresses(output)
+}
+
+// ParseMACAddresses parses the table printed by "display mac-address" and
+// "display location". Rows hold the service-port index, an optional bundle
+// index, port type, MAC, learn type, F/S/P, VPI (ONT ID on GPON ports), VCI
+// (GEM port) and VLAN.
+func ParseMACAddresses(output string) ([]MACAddressEntry, error) {
+	results := make([]MACAddressEntry, 0)
+
+	if strings.Contains(output, "There is not any MAC address record") {
+		return results, nil
+	}
+
+	lines := strings.Split(output, "\n")
+	err := parseLinesFailure(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		entry, ok := parseMACAddressLine(line)
+		if ok {
+			results = append(results, entry)
+		}
+	}
+
+	return results, nil
+}
+
+// ParseMACLocation parses "display location MAC", which prints the same row
+// as "display mac-address".
+func ParseMACLocation(output string) (*MACAddressEntry, error) {
+	entries, err := ParseMACAddresses(output)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, NotFoundError{}
+	}
+
+	return &entries[0], nil
+}
+
+func parseMACAddressLine(line string) (MACAddressEntry, bool) {
+	fields := strings.Fields(fspRegexp.ReplaceAllString(line, "$1/$2/$3"))
+	if len(fields) < 8 || !isNumber(fields[0]) {
+		return MACAddressEntry{}, false
+	}
+
+	for i, field := range fields {
+		if i < 3 || i+3 >= len(fields) || !fspRegexp.MatchString(field) {
+			continue
+		}
+
+		entry := MACAddressEntry{
+			ServicePortIndex: parseIntOrZero(fields[0]),
+			LearnType:        fields[i-1],
+			FSP:              field,
+			ONTID:            parseIntOrZero(fields[i+1]),
+			GemPort:          parseIntOrZero(fields[i+2]),
+			Vlan:             parseIntOrZero(fields[i+3]),
+		}
+
+		macIndex := -1
+		for j := 1; j < i-1; j++ {
+			if mac, err := parseMAC(fields[j]); err == nil {
+				entry.MAC = mac
+				macIndex = j
+			}
+		}
+		if macIndex < 1 {
+			return MACAddressEntry{}, false
+		}
+		entry.PortType = fields[macIndex-1]
+
+		return entry, true
+	}
+
+	return MACAddressEntry{}, false
+}
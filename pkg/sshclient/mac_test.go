@@ -0,0 +1,73 @@
+package sshclient
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseMACAddresses(t *testing.T) {
+	entries, err := ParseMACAddresses(readTestdata(t, "mac_address_port.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+
+	expected := MACAddressEntry{
+		ServicePortIndex: 12,
+		PortType:         "gpon",
+		MAC:              "00:e0:fc:12:34:56",
+		LearnType:        "dynamic",
+		FSP:              "0/1/3",
+		ONTID:            7,
+		GemPort:          20,
+		Vlan:             100,
+	}
+	if entries[0] != expected {
+		t.Fatalf("got %+v, want %+v", entries[0], expected)
+	}
+}
+
+func TestParseMACAddressesEmpty(t *testing.T) {
+	entries, err := ParseMACAddresses("  Failure: There is not any MAC address record\n(config)#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Fatalf("expected no entries, got %+v", entries)
+	}
+}
+
+func TestGetMACLocation(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display location 00e0-fc12-3457": readTestdata(t, "location.txt"),
+	})
+
+	location, err := executor.GetMACLocation("00:E0:FC:12:34:57")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display location 00e0-fc12-3457")
+
+	expected := MACAddressEntry{
+		ServicePortIndex: 13,
+		PortType:         "gpon",
+		MAC:              "00:e0:fc:12:34:57",
+		LearnType:        "dynamic",
+		FSP:              "0/1/3",
+		ONTID:            7,
+		GemPort:          21,
+		Vlan:             200,
+	}
+	if *location != expected {
+		t.Fatalf("got %+v, want %+v", *location, expected)
+	}
+}
+
+func TestParseMACLocationNotFound(t *testing.T) {
+	_, err := ParseMACLocation("  Failure: There is not any MAC address record\n(config)#")
+	if !errors.As(err, &NotFoundError{}) {
+		t.Fatalf("expected NotFoundError, got %v", err)
+	}
+}
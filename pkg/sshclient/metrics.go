@@ -0,0 +1,195 @@
+package sshclient
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Threshold is a [Min,Max] range as printed by the OLT. A bound is nil when
+// the OLT reports "-" for it.
+type Threshold struct {
+	Min *float64 `json:"min"`
+	Max *float64 `json:"max"`
+}
+
+type OpticalMetrics struct {
+	RxOpticalPower                 *float64  `json:"rx_optical_power_dbm"`
+	RxPowerCurrentWarningThreshold Threshold `json:"rx_power_current_warning_threshold_dbm"`
+	RxPowerCurrentAlarmThreshold   Threshold `json:"rx_power_current_alarm_threshold_dbm"`
+	TxOpticalPower                 *float64  `json:"tx_optical_power_dbm"`
+	TxPowerCurrentWarningThreshold Threshold `json:"tx_power_current_warning_threshold_dbm"`
+	TxPowerCurrentAlarmThreshold   Threshold `json:"tx_power_current_alarm_threshold_dbm"`
+	LaserBiasCurrent               *float64  `json:"laser_bias_current_ma"`
+	TxBiasCurrentWarningThreshold  Threshold `json:"tx_bias_current_warning_threshold_ma"`
+	TxBiasCurrentAlarmThreshold    Threshold `json:"tx_bias_current_alarm_threshold_ma"`
+	Temperature                    *float64  `json:"temperature_c"`
+	TemperatureWarningThreshold    Threshold `json:"temperature_warning_threshold_c"`
+	TemperatureAlarmThreshold      Threshold `json:"temperature_alarm_threshold_c"`
+	Voltage                        *float64  `json:"voltage_v"`
+	SupplyVoltageWarningThreshold  Threshold `json:"supply_voltage_warning_threshold_v"`
+	SupplyVoltageAlarmThreshold    Threshold `json:"supply_voltage_alarm_threshold_v"`
+	OLTRxONTOpticalPower           *float64  `json:"olt_rx_ont_optical_power_dbm"`
+	CATVRxOpticalPower             *float64  `json:"catv_rx_optical_power_dbm"`
+	CATVRxPowerAlarmThreshold      Threshold `json:"catv_rx_power_alarm_threshold_dbm"`
+}
+
+func (o *OpticalInfo) Metrics() OpticalMetrics {
+	return OpticalMetrics{
+		RxOpticalPower:                 parseOptionalFloat(o.RxOpticalPower),
+		RxPowerCurrentWarningThreshold: parseThreshold(o.RxPowerCurrentWarningThreshold),
+		RxPowerCurrentAlarmThreshold:   parseThreshold(o.RxPowerCurrentAlarmThreshold),
+		TxOpticalPower:                 parseOptionalFloat(o.TxOpticalPower),
+		TxPowerCurrentWarningThreshold: parseThreshold(o.TxPowerCurrentWarningThreshold),
+		TxPowerCurrentAlarmThreshold:   parseThreshold(o.TxPowerCurrentAlarmThreshold),
+		LaserBiasCurrent:               parseOptionalFloat(o.LaserBiasCurrent),
+		TxBiasCurrentWarningThreshold:  parseThreshold(o.TxBiasCurrentWarningThreshold),
+		TxBiasCurrentAlarmThreshold:    parseThreshold(o.TxBiasCurrentAlarmThreshold),
+		Temperature:                    parseOptionalFloat(o.Temperature),
+		TemperatureWarningThreshold:    parseThreshold(o.TemperatureWarningThreshold),
+		TemperatureAlarmThreshold:      parseThreshold(o.TemperatureAlarmThreshold),
+		Voltage:                        parseOptionalFloat(o.Voltage),
+		SupplyVoltageWarningThreshold:  parseThreshold(o.SupplyVoltageWarningThreshold),
+		SupplyVoltageAlarmThreshold:    parseThreshold(o.SupplyVoltageAlarmThreshold),
+		OLTRxONTOpticalPower:           parseOptionalFloat(o.OLTRxONTOpticalPower),
+		CATVRxOpticalPower:             parseOptionalFloat(o.CATVRxOpticalPower),
+		CATVRxPowerAlarmThreshold:      parseThreshold(o.CATVRxPowerAlarmThreshold),
+	}
+}
+
+type GeneralMetrics struct {
+	ID                *int           `json:"id"`
+	Distance          *int           `json:"distance_m"`
+	LastDistance      *int           `json:"last_distance_m"`
+	MemoryOccupation  *float64       `json:"memory_occupation_percent"`
+	CPUOccupation     *float64       `json:"cpu_occupation_percent"`
+	Temperature       *float64       `json:"temperature_c"`
+	LastUpTime        *time.Time     `json:"last_up_time"`
+	LastDownTime      *time.Time     `json:"last_down_time"`
+	LastDyingGaspTime *time.Time     `json:"last_dying_gasp_time"`
+	OnlineDuration    *time.Duration `json:"online_duration"`
+}
+
+func (o *GeneralInfo) Metrics() GeneralMetrics {
+	return GeneralMetrics{
+		ID:                parseOptionalInt(o.ID),
+		Distance:          parseOptionalInt(o.Distance),
+		LastDistance:      parseOptionalInt(o.LastDistance),
+		MemoryOccupation:  parseOptionalFloat(o.MemoryOccupation),
+		CPUOccupation:     parseOptionalFloat(o.CPUOccupation),
+		Temperature:       parseOptionalFloat(o.Temperature),
+		LastUpTime:        parseOptionalTime(o.LastUpTime),
+		LastDownTime:      parseOptionalTime(o.LastDownTime),
+		LastDyingGaspTime: parseOptionalTime(o.LastDyingGaspTime),
+		OnlineDuration:    parseOptionalDuration(o.OnlineDuration),
+	}
+}
+
+type ONTSummaryMetrics struct {
+	ID           *int       `json:"id"`
+	LastUpTime   *time.Time `json:"last_up_time"`
+	LastDownTime *time.Time `json:"last_down_time"`
+	Distance     *int       `json:"distance_m"`
+	RxPower      *float64   `json:"rx_power_dbm"`
+	TxPower      *float64   `json:"tx_power_dbm"`
+}
+
+func (o *ONTSummaryEntry) Metrics() ONTSummaryMetrics {
+	return ONTSummaryMetrics{
+		ID:           parseOptionalInt(o.ID),
+		LastUpTime:   parseOptionalTime(o.LastUpTime),
+		LastDownTime: parseOptionalTime(o.LastDownTime),
+		Distance:     parseOptionalInt(o.Distance),
+		RxPower:      parseOptionalFloat(o.RxPower),
+		TxPower:      parseOptionalFloat(o.TxPower),
+	}
+}
+
+var (
+	leadingNumberRegexp  = regexp.MustCompile(`^[-+]?\d+(\.\d+)?`)
+	onlineDurationRegexp = regexp.MustCompile(`(\d+)\s*(day|hour|minute|second)`)
+)
+
+// parseOptionalFloat reads the number at the start of a value such as
+// "-21.35", "3.280", "45(C)" or "5%". It returns nil for "-" and anything
+// else that does not start with a number.
+func parseOptionalFloat(value string) *float64 {
+	match := leadingNumberRegexp.FindString(strings.TrimSpace(value))
+	if match == "" {
+		return nil
+	}
+	number, err := strconv.ParseFloat(match, 64)
+	if err != nil {
+		return nil
+	}
+	return &number
+}
+
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+func parseOptionalInt(value string) *int {
+	number, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return nil
+	}
+	return &number
+}
+
+func parseThreshold(value string) Threshold {
+	value = strings.Trim(strings.TrimSpace(value), "[]")
+	bounds := strings.Split(value, ",")
+	if len(bounds) != 2 {
+		return Threshold{}
+	}
+	return Threshold{Min: parseOptionalFloat(bounds[0]), Max: parseOptionalFloat(bounds[1])}
+}
+
+// parseOptionalTime reads times with a UTC offset and falls back to local
+// time for the summary tables, which print none.
+func parseOptionalTime(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	parsed, err := time.Parse("2006-01-02 15:04:05-07:00", parseDateTime(value))
+	if err != nil {
+		parsed, err = time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	}
+	if err != nil {
+		return nil
+	}
+	return &parsed
+}
+
+func parseOptionalDuration(value string) *time.Duration {
+	duration, ok := parseOnlineDuration(value)
+	if !ok {
+		return nil
+	}
+	return &duration
+}
+
+// parseOnlineDuration parses durations printed as
+// "1 day(s), 2 hour(s), 13 minute(s), 46 second(s)".
+func parseOnlineDuration(value string) (time.Duration, bool) {
+	units := map[string]time.Duration{
+		"day":    24 * time.Hour,
+		"hour":   time.Hour,
+		"minute": time.Minute,
+		"second": time.Second,
+	}
+
+	var duration time.Duration
+	matches := onlineDurationRegexp.FindAllStringSubmatch(value, -1)
+	for _, match := range matches {
+		amount, err := strconv.Atoi(match[1])
+		if err != nil {
+			continue
+		}
+		duration += time.Duration(amount) * units[match[2]]
+	}
+	return duration, len(matches) > 0
+}
@@ -0,0 +1,67 @@
+package sshclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGeneralInfoMetrics(t *testing.T) {
+	info, err := ParseGeneralInfo(readTestdata(t, "ont_info.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	metrics := info.Metrics()
+
+	if metrics.Distance == nil || *metrics.Distance != 1520 {
+		t.Fatalf("unexpected distance %v", metrics.Distance)
+	}
+	if metrics.Temperature == nil || *metrics.Temperature != 48 {
+		t.Fatalf("unexpected temperature %v", metrics.Temperature)
+	}
+	expectedUp := time.Date(2023, 5, 1, 10, 11, 12, 0, time.FixedZone("", 8*60*60))
+	if metrics.LastUpTime == nil || !metrics.LastUpTime.Equal(expectedUp) {
+		t.Fatalf("unexpected last up time %v", metrics.LastUpTime)
+	}
+	expectedDuration := 26*time.Hour + 13*time.Minute + 46*time.Second
+	if metrics.OnlineDuration == nil || *metrics.OnlineDuration != expectedDuration {
+		t.Fatalf("unexpected online duration %v", metrics.OnlineDuration)
+	}
+}
+
+func TestGeneralInfoMetricsOffline(t *testing.T) {
+	info := &GeneralInfo{OnlineDuration: "-", LastUpTime: "-"}
+	metrics := info.Metrics()
+	if metrics.OnlineDuration != nil {
+		t.Fatalf("expected an unknown online duration, got %v", *metrics.OnlineDuration)
+	}
+	if metrics.LastUpTime != nil {
+		t.Fatalf("expected an unknown last up time, got %v", *metrics.LastUpTime)
+	}
+}
+
+func TestONTSummaryMetricsLocalTime(t *testing.T) {
+	summary, err := ParseONTSummary(readTestdata(t, "ont_info_summary.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.FSP != "0/1/0" || summary.Total != 2 || summary.Online != 1 || len(summary.ONTs) != 2 {
+		t.Fatalf("unexpected summary %+v", summary)
+	}
+
+	metrics := summary.ONTs[0].Metrics()
+	expected := time.Date(2023, 5, 1, 10, 11, 12, 0, time.Local)
+	if metrics.LastUpTime == nil || !metrics.LastUpTime.Equal(expected) {
+		t.Fatalf("unexpected last up time %v", metrics.LastUpTime)
+	}
+	if metrics.RxPower == nil || *metrics.RxPower != -21.55 {
+		t.Fatalf("unexpected rx power %v", metrics.RxPower)
+	}
+
+	offline := summary.ONTs[1].Metrics()
+	if offline.LastUpTime != nil || offline.Distance != nil || offline.RxPower != nil {
+		t.Fatalf("expected unknown values for the offline ONT, got %+v", offline)
+	}
+	if offline.LastDownTime == nil {
+		t.Fatal("expected a last down time for the offline ONT")
+	}
+}
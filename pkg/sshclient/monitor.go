@@ -0,0 +1,193 @@
+package sshclient
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+type EventSource string
+
+const (
+	EventSourceCLI  EventSource = "cli"
+	EventSourceSNMP EventSource = "snmp"
+)
+
+// Event is an alarm or event notification pushed by the OLT, either printed
+// asynchronously into a CLI session or sent as an SNMP trap.
+type Event struct {
+	Source     EventSource `json:"source"`
+	ReceivedAt time.Time   `json:"received_at"`
+	Alarm      Alarm       `json:"alarm"`
+	Raw        string      `json:"raw"`
+}
+
+var (
+	unsolicitedHeaderRegexp      = regexp.MustCompile(`^\s*(ALARM|EVENT)\s+\d+\s+`)
+	unsolicitedHeaderStartRegexp = regexp.MustCompile(`(?m)^[ \t]*(?:ALARM|EVENT)[ \t]+\d+[ \t]+`)
+	unsolicitedBlockRegexp       = regexp.MustCompile(`(?ms)^[ \t]*(?:ALARM|EVENT)[ \t]+\d+[ \t]+.*?--- END[^\n]*\n?`)
+)
+
+// stripUnsolicitedMessages removes complete alarm and event notifications
+// from command output.
+func stripUnsolicitedMessages(output string) string {
+	return unsolicitedBlockRegexp.ReplaceAllString(output, "")
+}
+
+type AlarmMonitorOptions struct {
+	Verbose           bool
+	KeepaliveInterval time.Duration
+	BufferSize        int
+}
+
+// AlarmMonitor is a dedicated CLI session that enables alarm output and
+// delivers every notification printed by the OLT on Events. It should run on
+// its own ConnectionManager, separate from the sessions used for commands.
+type AlarmMonitor struct {
+	executor     *CommandExecutor
+	closeSession func() error
+	events       chan Event
+	done         chan struct{}
+	once         sync.Once
+	mu           sync.Mutex
+	err          error
+}
+
+func NewAlarmMonitor(connManager *ConnectionManager, options AlarmMonitorOptions) (*AlarmMonitor, error) {
+	executor, err := NewCommandExecutor(connManager, CommandExecutorOptions{Verbose: options.Verbose})
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = executor.ExecuteCommand("alarm output all", "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+
+	_, err = executor.ExecuteCommand("terminal monitor", "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+
+	return startAlarmMonitor(executor, connManager.Close, options), nil
+}
+
+// startAlarmMonitor reads notifications from a session that already has alarm
+// output enabled. closeSession ends the session on Close.
+func startAlarmMonitor(executor *CommandExecutor, closeSession func() error, options AlarmMonitorOptions) *AlarmMonitor {
+	if options.BufferSize <= 0 {
+		options.BufferSize = 64
+	}
+	if options.KeepaliveInterval <= 0 {
+		options.KeepaliveInterval = time.Minute
+	}
+
+	monitor := &AlarmMonitor{
+		executor:     executor,
+		closeSession: closeSession,
+		events:       make(chan Event, options.BufferSize),
+		done:         make(chan struct{}),
+	}
+
+	go monitor.read()
+	go monitor.keepalive(options.KeepaliveInterval)
+
+	return monitor
+}
+
+// Events is closed when the session ends or Close is called; Err then
+// reports why the session ended.
+func (m *AlarmMonitor) Events() <-chan Event {
+	return m.events
+}
+
+func (m *AlarmMonitor) Err() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+func (m *AlarmMonitor) Close() error {
+	var err error
+	m.once.Do(func() {
+		close(m.done)
+		err = m.closeSession()
+	})
+	return err
+}
+
+func (m *AlarmMonitor) read() {
+	defer close(m.events)
+
+	output := make([]byte, 4096)
+	var buffer string
+	for {
+		text, err := m.executor.readChunk(output)
+		if err != nil {
+			select {
+			case <-m.done:
+			default:
+				m.mu.Lock()
+				m.err = err
+				m.mu.Unlock()
+			}
+			return
+		}
+
+		buffer += text
+		for {
+			location := unsolicitedBlockRegexp.FindStringIndex(buffer)
+			if location == nil {
+				break
+			}
+
+			raw := buffer[location[0]:location[1]]
+			buffer = buffer[location[1]:]
+
+			alarm, ok := ParseAlarmBlock(strings.Split(strings.TrimSpace(strings.ReplaceAll(raw, "\r", "")), "\n"))
+			if !ok {
+				continue
+			}
+
+			event := Event{Source: EventSourceCLI, ReceivedAt: time.Now(), Alarm: alarm, Raw: raw}
+			select {
+			case m.events <- event:
+			case <-m.done:
+				return
+			}
+		}
+
+		buffer = trimMonitorBuffer(buffer)
+	}
+}
+
+func (m *AlarmMonitor) keepalive(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			err := m.executor.write("\n")
+			if err != nil {
+				return
+			}
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// trimMonitorBuffer drops everything before the start of a notification that
+// is still being received, so prompts and keepalive echoes do not pile up.
+func trimMonitorBuffer(buffer string) string {
+	if location := unsolicitedHeaderStartRegexp.FindStringIndex(buffer); location != nil {
+		return buffer[location[0]:]
+	}
+	if index := strings.LastIndex(buffer, "\n"); index >= 0 {
+		return buffer[index+1:]
+	}
+	return buffer
+}
@@ -0,0 +1,233 @@
+package sshclient
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const unsolicitedAlarm = `  ALARM 1234 FAULT MAJOR 0x2e112001 GPON 2024-01-02 10:11:12
+  ALARM NAME :The ONT LOS
+  PARAMETERS :FrameID: 0, SlotID: 1, PortID: 3, ONT ID: 7
+  --- END
+`
+
+func TestExecuteCommandKeepsUnsolicitedMessagesByDefault(t *testing.T) {
+	executor, _ := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display sysuptime": "  System up time: 1 day(s)\n" + unsolicitedAlarm + "(config)#",
+	})
+
+	output, err := executor.ExecuteCommand("display sysuptime", "(config)#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(output, "ALARM 1234") {
+		t.Fatalf("expected the alarm to be kept, got %q", output)
+	}
+}
+
+func TestExecuteCommandFiltersUnsolicitedMessages(t *testing.T) {
+	executor, _ := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display sysuptime": "  System up time: 1 day(s)\n" + unsolicitedAlarm + "(config)#",
+	})
+	executor.FilterUnsolicited = true
+
+	output, err := executor.ExecuteCommand("display sysuptime", "(config)#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(output, "ALARM") || !strings.Contains(output, "System up time") {
+		t.Fatalf("expected only the command output, got %q", output)
+	}
+}
+
+// overlapDetector fails when two writes are in progress at the same time.
+type overlapDetector struct {
+	writing  int32
+	overlaps int32
+}
+
+func (o *overlapDetector) Write(p []byte) (int, error) {
+	if !atomic.CompareAndSwapInt32(&o.writing, 0, 1) {
+		atomic.AddInt32(&o.overlaps, 1)
+		return len(p), nil
+	}
+	time.Sleep(time.Millisecond)
+	atomic.StoreInt32(&o.writing, 0)
+	return len(p), nil
+}
+
+func (o *overlapDetector) Close() error {
+	return nil
+}
+
+func TestWritesAreSerialised(t *testing.T) {
+	detector := &overlapDetector{}
+	executor := &CommandExecutor{Stdin: detector}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				if err := executor.write("\n"); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if overlaps := atomic.LoadInt32(&detector.overlaps); overlaps != 0 {
+		t.Fatalf("%d writes overlapped", overlaps)
+	}
+}
+
+// recordingInput collects what is written to the session.
+type recordingInput struct {
+	mu     sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (r *recordingInput) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buffer.Write(p)
+}
+
+func (r *recordingInput) Close() error {
+	return nil
+}
+
+func (r *recordingInput) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.buffer.String()
+}
+
+func startTestMonitor(t *testing.T, keepalive time.Duration) (*AlarmMonitor, *io.PipeWriter, *recordingInput) {
+	t.Helper()
+	reader, writer := io.Pipe()
+	input := &recordingInput{}
+	executor := &CommandExecutor{Stdout: reader, Stdin: input, ExecutorContext: ExecutorContext{Level: 2}}
+	monitor := startAlarmMonitor(executor, reader.Close, AlarmMonitorOptions{KeepaliveInterval: keepalive})
+	t.Cleanup(func() { monitor.Close() })
+	return monitor, writer, input
+}
+
+func feed(t *testing.T, writer *io.PipeWriter, chunks ...string) {
+	t.Helper()
+	for _, chunk := range chunks {
+		if _, err := writer.Write([]byte(chunk)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func receiveMonitorEvent(t *testing.T, monitor *AlarmMonitor) Event {
+	t.Helper()
+	select {
+	case event, ok := <-monitor.Events():
+		if !ok {
+			t.Fatalf("events closed: %v", monitor.Err())
+		}
+		return event
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event received")
+	}
+	return Event{}
+}
+
+func TestAlarmMonitorDecodesChunkedNotifications(t *testing.T) {
+	monitor, writer, _ := startTestMonitor(t, time.Hour)
+
+	feed(t, writer,
+		"\r\n(config)#\r\n  ALARM 1234 FAULT MAJOR 0x2e112001 GPON 2024-01-02 10:11:12\r\n  ALARM NAME :The O",
+		"NT LOS\r\n  PARAMETERS :FrameID: 0, SlotID: 1, PortID: 3, ONT ID: 7\r\n  --- E",
+		"ND\r\n\r\n(config)#",
+		"\r\n  ALARM 1235 RECOVERY MAJOR 0x2e112001 GPON 2024-01-02 10:15:00\r\n  ALARM NAME :The ONT LOS\r\n  PARAMETERS :FrameID: 0, SlotID: 1, PortID: 3, ONT ID: 7\r\n  --- END\r\n",
+	)
+
+	fault := receiveMonitorEvent(t, monitor)
+	if fault.Source != EventSourceCLI || fault.Alarm.SequenceNumber != 1234 || fault.Alarm.Type != AlarmTypeFault || fault.Alarm.Name != "The ONT LOS" || *fault.Alarm.ONTID != 7 {
+		t.Fatalf("unexpected event %+v", fault)
+	}
+	if !strings.HasPrefix(strings.TrimSpace(fault.Raw), "ALARM 1234") || strings.Contains(fault.Raw, "(config)#") {
+		t.Fatalf("unexpected raw notification %q", fault.Raw)
+	}
+
+	recovery := receiveMonitorEvent(t, monitor)
+	if recovery.Alarm.SequenceNumber != 1235 || recovery.Alarm.Type != AlarmTypeRecovery || recovery.Alarm.ClearedAt == nil {
+		t.Fatalf("unexpected event %+v", recovery)
+	}
+}
+
+func TestAlarmMonitorIgnoresKeepaliveEchoes(t *testing.T) {
+	monitor, writer, input := startTestMonitor(t, 10*time.Millisecond)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !strings.Contains(input.String(), "\n") {
+		if time.Now().After(deadline) {
+			t.Fatal("no keepalive sent")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	feed(t, writer, "\r\n(config)#", "\r\n(config)#", "\r\n  EVENT 77 EVENT WARNING 0x2e2d2003 GPON 2024-01-02 10:20:00\r\n  EVENT NAME :The ONT is online\r\n  --- END\r\n")
+
+	event := receiveMonitorEvent(t, monitor)
+	if event.Alarm.SequenceNumber != 77 || event.Alarm.Type != AlarmTypeEvent || strings.Contains(event.Raw, "(config)#") {
+		t.Fatalf("unexpected event %+v", event)
+	}
+}
+
+func TestAlarmMonitorCloseClosesEvents(t *testing.T) {
+	monitor, _, _ := startTestMonitor(t, time.Hour)
+
+	if err := monitor.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case _, ok := <-monitor.Events():
+		if ok {
+			t.Fatal("expected no events")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("events not closed")
+	}
+	if err := monitor.Err(); err != nil {
+		t.Fatalf("expected no error after Close, got %v", err)
+	}
+}
+
+func TestAlarmMonitorReportsSessionEnd(t *testing.T) {
+	monitor, writer, _ := startTestMonitor(t, time.Hour)
+
+	writer.Close()
+	for range monitor.Events() {
+	}
+	if monitor.Err() != io.EOF {
+		t.Fatalf("expected io.EOF, got %v", monitor.Err())
+	}
+}
+
+func TestTrimMonitorBuffer(t *testing.T) {
+	tests := []struct {
+		buffer   string
+		expected string
+	}{
+		{"\r\n(config)#\r\n(config)#", "(config)#"},
+		{"(config)#\r\n  ALARM 1 FAULT MAJOR 0x1 GPON\r\n  ALARM NAME :The", "  ALARM 1 FAULT MAJOR 0x1 GPON\r\n  ALARM NAME :The"},
+		{"partial line", "partial line"},
+	}
+	for _, test := range tests {
+		if trimmed := trimMonitorBuffer(test.buffer); trimmed != test.expected {
+			t.Errorf("trimMonitorBuffer(%q) = %q, want %q", test.buffer, trimmed, test.expected)
+		}
+	}
+}
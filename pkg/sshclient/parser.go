@@ -155,6 +155,108 @@ func ParseOpticalInfo(output string) (*OpticalInfo, error) {
 	return details, nil
 }
 
+type ONTOpticalReading struct {
+	FSP         string   `json:"fsp"`
+	ONTID       int      `json:"ont_id"`
+	RxPower     *float64 `json:"rx_power_dbm"`
+	TxPower     *float64 `json:"tx_power_dbm"`
+	OLTRxPower  *float64 `json:"olt_rx_power_dbm"`
+	Temperature *float64 `json:"temperature_c"`
+	Voltage     *float64 `json:"voltage_v"`
+	BiasCurrent *float64 `json:"bias_current_ma"`
+}
+
+func (o *ONTOpticalReading) GetFrameSlotPort() (int, int, int, error) {
+	return getFrameSlotPortFromFSP(o.FSP)
+}
+
+// ParseONTOpticalReadings parses the summary table printed by
+// "display ont optical-info P all". Board-wide output prefixes every row
+// with the F/S/P of the ONT; for port-wide output defaultFSP is used.
+func ParseONTOpticalReadings(output string, defaultFSP string) ([]ONTOpticalReading, error) {
+	results := make([]ONTOpticalReading, 0)
+
+	for _, line := range strings.Split(output, "\n") {
+		err := parseFailure(line)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(fspRegexp.ReplaceAllString(line, "$1/$2/$3"))
+		fsp := defaultFSP
+		if len(fields) > 0 && fspRegexp.MatchString(fields[0]) {
+			fsp = fields[0]
+			fields = fields[1:]
+		}
+
+		if len(fields) != 7 || !isNumber(fields[0]) || !isReading(fields[1:]) {
+			continue
+		}
+
+		ontID, _ := strconv.Atoi(fields[0])
+		results = append(results, ONTOpticalReading{
+			FSP:         fsp,
+			ONTID:       ontID,
+			RxPower:     parseOptionalFloat(fields[1]),
+			TxPower:     parseOptionalFloat(fields[2]),
+			OLTRxPower:  parseOptionalFloat(fields[3]),
+			Temperature: parseOptionalFloat(fields[4]),
+			Voltage:     parseOptionalFloat(fields[5]),
+			BiasCurrent: parseOptionalFloat(fields[6]),
+		})
+	}
+
+	return results, nil
+}
+
+type ONTEthernetPortState struct {
+	ONTID      int    `json:"ont_id"`
+	PortID     int    `json:"port_id"`
+	PortType   string `json:"port_type"`
+	Speed      *int   `json:"speed_mbps"`
+	Duplex     string `json:"duplex"`
+	LinkState  string `json:"link_state"`
+	LinkUp     bool   `json:"link_up"`
+	RingStatus string `json:"ring_status"`
+}
+
+// ParseONTEthernetPortStates parses "display ont port state P ONTID
+// eth-port all", which prints one row per Ethernet port of the ONT.
+func ParseONTEthernetPortStates(output string) ([]ONTEthernetPortState, error) {
+	results := make([]ONTEthernetPortState, 0)
+
+	for _, line := range strings.Split(output, "\n") {
+		err := parseFailure(line)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 6 || !isNumber(fields[0]) || !isNumber(fields[1]) {
+			continue
+		}
+
+		ontID, _ := strconv.Atoi(fields[0])
+		portID, _ := strconv.Atoi(fields[1])
+		state := ONTEthernetPortState{
+			ONTID:     ontID,
+			PortID:    portID,
+			PortType:  fields[2],
+			Speed:     parseOptionalInt(fields[3]),
+			Duplex:    fields[4],
+			LinkState: fields[5],
+			LinkUp:    strings.EqualFold(fields[5], "up"),
+		}
+		if len(fields) > 6 {
+			state.RingStatus = fields[6]
+		}
+
+		results = append(results, state)
+	}
+
+	return results, nil
+}
+
 type GeneralInfo struct {
 	FSP               string `json:"fsp"`
 	ID                string `json:"id"`
@@ -200,53 +302,328 @@ func ParseGeneralInfoBySn(output string) (*GeneralInfo, error) {
 			return nil, InvalidSerialNumberError{}
 		}
 
-		fieldMap := map[string]*string{
-			"F/S/P                   : ": &ont.FSP,
-			"ONT-ID                  : ": &ont.ID,
-			"Control flag            : ": &ont.ControlFlag,
-			"Run state               : ": &ont.RunState,
-			"Config state            : ": &ont.ConfigState,
-			"Match state             : ": &ont.MatchState,
-			"DBA type                : ": &ont.DBAType,
-			"ONT distance(m)         : ": &ont.Distance,
-			"ONT last distance(m)    : ": &ont.LastDistance,
-			"ONT battery state       : ": &ont.BatteryState,
-			"Memory occupation       : ": &ont.MemoryOccupation,
-			"CPU occupation          : ": &ont.CPUOccupation,
-			"Temperature             : ": &ont.Temperature,
-			"Authentic type          : ": &ont.AuthenticType,
-			"SN                      : ": &ont.SN,
-			"Management mode         : ": &ont.ManagementMode,
-			"Software work mode      : ": &ont.SoftwareWorkMode,
-			"Isolation state         : ": &ont.IsolationState,
-			"Description             : ": &ont.Description,
-			"Last down cause         : ": &ont.LatDownCause,
-			"Last up time            : ": &ont.LastUpTime,
-			"Last down time          : ": &ont.LastDownTime,
-			"Last dying gasp time    : ": &ont.LastDyingGaspTime,
-			"ONT online duration     : ": &ont.OnlineDuration,
+		parseGeneralInfoLine(ont, trimmedLine)
+	}
+
+	return ont, nil
+}
+
+func ParseGeneralInfo(output string) (*GeneralInfo, error) {
+	lines := strings.Split(output, "\n")
+
+	ont := &GeneralInfo{}
+
+	for _, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "The required ONT does not exist" {
+			return nil, NotFoundError{}
 		}
 
-		for prefix, field := range fieldMap {
-			if strings.HasPrefix(trimmedLine, prefix) {
-				if strings.Contains(prefix, "Last up time") ||
-					strings.Contains(prefix, "Last down time") ||
-					strings.Contains(prefix, "Last dying gasp time") {
-					*field = parseDateTime(strings.TrimPrefix(trimmedLine, prefix))
-					break
-				}
-				*field = strings.TrimPrefix(trimmedLine, prefix)
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		parseGeneralInfoLine(ont, trimmedLine)
+	}
+
+	if ont.FSP == "" {
+		return nil, NotFoundError{}
+	}
+
+	return ont, nil
+}
+
+// ParseGeneralInfoList parses outputs that describe several ONTs, such as
+// "display ont info by-desc". A new ONT starts at every F/S/P line.
+func ParseGeneralInfoList(output string) ([]GeneralInfo, error) {
+	results := make([]GeneralInfo, 0)
+	lines := strings.Split(output, "\n")
+
+	var ont *GeneralInfo
+	for _, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "The required ONT does not exist" {
+			return results, nil
+		}
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		if strings.HasPrefix(trimmedLine, "F/S/P ") {
+			if ont != nil {
+				results = append(results, *ont)
+			}
+			ont = &GeneralInfo{}
+		}
+
+		if ont != nil {
+			parseGeneralInfoLine(ont, trimmedLine)
+		}
+	}
+
+	if ont != nil {
+		results = append(results, *ont)
+	}
+
+	return results, nil
+}
+
+func parseGeneralInfoLine(ont *GeneralInfo, trimmedLine string) {
+	fieldMap := map[string]*string{
+		"F/S/P                   : ": &ont.FSP,
+		"ONT-ID                  : ": &ont.ID,
+		"Control flag            : ": &ont.ControlFlag,
+		"Run state               : ": &ont.RunState,
+		"Config state            : ": &ont.ConfigState,
+		"Match state             : ": &ont.MatchState,
+		"DBA type                : ": &ont.DBAType,
+		"ONT distance(m)         : ": &ont.Distance,
+		"ONT last distance(m)    : ": &ont.LastDistance,
+		"ONT battery state       : ": &ont.BatteryState,
+		"Memory occupation       : ": &ont.MemoryOccupation,
+		"CPU occupation          : ": &ont.CPUOccupation,
+		"Temperature             : ": &ont.Temperature,
+		"Authentic type          : ": &ont.AuthenticType,
+		"SN                      : ": &ont.SN,
+		"Management mode         : ": &ont.ManagementMode,
+		"Software work mode      : ": &ont.SoftwareWorkMode,
+		"Isolation state         : ": &ont.IsolationState,
+		"Description             : ": &ont.Description,
+		"Last down cause         : ": &ont.LatDownCause,
+		"Last up time            : ": &ont.LastUpTime,
+		"Last down time          : ": &ont.LastDownTime,
+		"Last dying gasp time    : ": &ont.LastDyingGaspTime,
+		"ONT online duration     : ": &ont.OnlineDuration,
+	}
+
+	for prefix, field := range fieldMap {
+		if strings.HasPrefix(trimmedLine, prefix) {
+			if strings.Contains(prefix, "Last up time") ||
+				strings.Contains(prefix, "Last down time") ||
+				strings.Contains(prefix, "Last dying gasp time") {
+				*field = parseDateTime(strings.TrimPrefix(trimmedLine, prefix))
 				break
 			}
+			*field = strings.TrimPrefix(trimmedLine, prefix)
+			break
 		}
 	}
+}
 
-	return ont, nil
+type ONTVersion struct {
+	FSP                    string `json:"fsp"`
+	ONTID                  string `json:"ont_id"`
+	VendorID               string `json:"vendor_id"`
+	ONTVersion             string `json:"ont_version"`
+	ProductID              string `json:"product_id"`
+	EquipmentID            string `json:"equipment_id"`
+	MainSoftwareVersion    string `json:"main_software_version"`
+	StandbySoftwareVersion string `json:"standby_software_version"`
+	ProductDescription     string `json:"product_description"`
+}
+
+func (o *ONTVersion) GetFrameSlotPort() (int, int, int, error) {
+	return getFrameSlotPortFromFSP(o.FSP)
+}
+
+func ParseONTVersion(output string) (*ONTVersion, error) {
+	versions, err := ParseONTVersions(output)
+	if err != nil {
+		return nil, err
+	}
+	if len(versions) == 0 {
+		return nil, NotFoundError{}
+	}
+	return &versions[0], nil
+}
+
+// ParseONTVersions parses "display ont version" for a single ONT, a port or
+// a board. A new ONT starts at every F/S/P line.
+func ParseONTVersions(output string) ([]ONTVersion, error) {
+	results := make([]ONTVersion, 0)
+	lines := strings.Split(output, "\n")
+
+	var version *ONTVersion
+	for _, line := range lines {
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "The required ONT does not exist" {
+			return results, nil
+		}
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		key, value, ok := splitKeyValue(trimmedLine)
+		if !ok {
+			continue
+		}
+
+		if key == "f/s/p" {
+			if version != nil {
+				results = append(results, *version)
+			}
+			version = &ONTVersion{}
+		}
+		if version == nil {
+			continue
+		}
+
+		fieldMap := map[string]*string{
+			"f/s/p":                  &version.FSP,
+			"ontid":                  &version.ONTID,
+			"vendorid":               &version.VendorID,
+			"ontversion":             &version.ONTVersion,
+			"productid":              &version.ProductID,
+			"equipmentid":            &version.EquipmentID,
+			"mainsoftwareversion":    &version.MainSoftwareVersion,
+			"standbysoftwareversion": &version.StandbySoftwareVersion,
+			"ontproductdescription":  &version.ProductDescription,
+		}
+
+		if field, ok := fieldMap[key]; ok {
+			*field = value
+		}
+	}
+
+	if version != nil {
+		results = append(results, *version)
+	}
+
+	return results, nil
+}
+
+type ONTSummary struct {
+	FSP    string            `json:"fsp"`
+	Total  int               `json:"total"`
+	Online int               `json:"online"`
+	ONTs   []ONTSummaryEntry `json:"onts"`
+}
+
+func (o *ONTSummary) GetFrameSlotPort() (int, int, int, error) {
+	return getFrameSlotPortFromFSP(o.FSP)
+}
+
+type ONTSummaryEntry struct {
+	ID            string `json:"id"`
+	RunState      string `json:"run_state"`
+	LastUpTime    string `json:"last_up_time"`
+	LastDownTime  string `json:"last_down_time"`
+	LastDownCause string `json:"last_down_cause"`
+	SN            string `json:"sn"`
+	Type          string `json:"type"`
+	Distance      string `json:"distance"`
+	RxPower       string `json:"rx_power"`
+	TxPower       string `json:"tx_power"`
+	Description   string `json:"description"`
+}
+
+// ParseONTSummary parses "display ont info summary F/S/P". The OLT prints
+// the state of every ONT in a first table and its SN, type, distance and
+// optical power in a second one; rows of both tables are merged by ONT ID.
+func ParseONTSummary(output string) (*ONTSummary, error) {
+	summary := &ONTSummary{ONTs: make([]ONTSummaryEntry, 0)}
+	entries := map[string]int{}
+	inventoryTable := false
+
+	countRegexp := regexp.MustCompile(`In port (\d+\s*/\s*\d+\s*/\s*\d+)\s*,\s*the total of ONTs are:\s*(\d+),\s*online:\s*(\d+)`)
+
+	lines := strings.Split(output, "\n")
+	err := parseLinesFailure(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		if match := countRegexp.FindStringSubmatch(line); match != nil {
+			summary.FSP = fspRegexp.ReplaceAllString(match[1], "$1/$2/$3")
+			summary.Total, _ = strconv.Atoi(match[2])
+			summary.Online, _ = strconv.Atoi(match[3])
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "ONT" && (fields[1] == "SN" || strings.Contains(line, " SN ")) {
+			inventoryTable = true
+			continue
+		}
+
+		if len(fields) < 2 || !isNumber(fields[0]) {
+			continue
+		}
+
+		index, ok := entries[fields[0]]
+		if !ok {
+			summary.ONTs = append(summary.ONTs, ONTSummaryEntry{ID: fields[0]})
+			index = len(summary.ONTs) - 1
+			entries[fields[0]] = index
+		}
+		entry := &summary.ONTs[index]
+
+		if inventoryTable {
+			parseONTSummaryInventory(entry, fields)
+		} else {
+			parseONTSummaryState(entry, fields)
+		}
+	}
+
+	return summary, nil
+}
+
+func parseONTSummaryState(entry *ONTSummaryEntry, fields []string) {
+	entry.RunState = fields[1]
+	rest := fields[2:]
+	entry.LastUpTime, rest = takeDateTime(rest)
+	entry.LastDownTime, rest = takeDateTime(rest)
+	entry.LastDownCause = strings.Join(rest, " ")
+}
+
+func parseONTSummaryInventory(entry *ONTSummaryEntry, fields []string) {
+	if len(fields) < 5 {
+		return
+	}
+	entry.SN = fields[1]
+	entry.Type = fields[2]
+	entry.Distance = fields[3]
+	if powers := strings.SplitN(fields[4], "/", 2); len(powers) == 2 {
+		entry.RxPower = powers[0]
+		entry.TxPower = powers[1]
+	}
+	entry.Description = strings.Join(fields[5:], " ")
+}
+
+// takeDateTime consumes a "-" placeholder or a "date time" pair from fields.
+func takeDateTime(fields []string) (string, []string) {
+	if len(fields) == 0 {
+		return "", fields
+	}
+	if fields[0] == "-" || len(fields) == 1 || !strings.Contains(fields[1], ":") {
+		return fields[0], fields[1:]
+	}
+	return parseDateTime(fields[0] + " " + fields[1]), fields[2:]
 }
 
 type ServicePort struct {
-	Index int `json:"index"`
-	Vlan  int `json:"vlan"`
+	Index          int    `json:"index"`
+	Vlan           int    `json:"vlan"`
+	VlanAttr       string `json:"vlan_attr"`
+	PortType       string `json:"port_type"`
+	FSP            string `json:"fsp"`
+	ONTID          int    `json:"ont_id"`
+	GemPort        int    `json:"gem_port"`
+	FlowType       string `json:"flow_type"`
+	FlowParameter  string `json:"flow_parameter"`
+	RxTrafficTable int    `json:"rx_traffic_table"`
+	TxTrafficTable int    `json:"tx_traffic_table"`
+	State          string `json:"state"`
+}
+
+func (s *ServicePort) GetFrameSlotPort() (int, int, int, error) {
+	return getFrameSlotPortFromFSP(s.FSP)
 }
 
 func ParseServicePorts(output string) ([]ServicePort, error) {
@@ -264,29 +641,167 @@ func ParseServicePorts(output string) ([]ServicePort, error) {
 		}
 	}
 
-	re := regexp.MustCompile(`\s+(\d+)\s+(\d+)\s+\w+\s+\w+\s+\d+/\d+ /\d+\s+\d+\s+\d+\s+\w+\s+\w+\s+\d+\s+\d+\s+\w+`)
+	for _, line := range lines {
+		servicePort, ok := ParseServicePortLine(line)
+		if ok {
+			results = append(results, servicePort)
+		}
+	}
 
-	matches := re.FindAllStringSubmatch(output, -1)
+	return results, nil
+}
 
-	for _, match := range matches {
-		if len(match) >= 3 {
-			index, err := strconv.Atoi(match[1])
-			if err != nil {
-				return nil, err
-			}
+// ParseServicePortLine parses a single row of the "display service-port"
+// table. The F/S/P column may be padded ("0/1 /3") and FLOW PARA may be
+// empty.
+func ParseServicePortLine(line string) (ServicePort, bool) {
+	line = fspRegexp.ReplaceAllString(line, "$1/$2/$3")
+	fields := strings.Fields(line)
+	if len(fields) != 11 && len(fields) != 12 {
+		return ServicePort{}, false
+	}
 
-			vlan, err := strconv.Atoi(match[2])
-			if err != nil {
-				return nil, err
-			}
+	state := fields[len(fields)-1]
+	if state != "up" && state != "down" {
+		return ServicePort{}, false
+	}
+
+	index, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return ServicePort{}, false
+	}
 
-			results = append(results, ServicePort{Index: index, Vlan: vlan})
+	vlan, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return ServicePort{}, false
+	}
+
+	if !fspRegexp.MatchString(fields[4]) {
+		return ServicePort{}, false
+	}
+
+	servicePort := ServicePort{
+		Index:    index,
+		Vlan:     vlan,
+		VlanAttr: fields[2],
+		PortType: fields[3],
+		FSP:      fields[4],
+		ONTID:    parseIntOrZero(fields[5]),
+		GemPort:  parseIntOrZero(fields[6]),
+		FlowType: fields[7],
+		State:    state,
+	}
+
+	traffic := fields[8:]
+	if len(fields) == 12 {
+		servicePort.FlowParameter = fields[8]
+		traffic = fields[9:]
+	}
+	servicePort.RxTrafficTable = parseIntOrZero(traffic[0])
+	servicePort.TxTrafficTable = parseIntOrZero(traffic[1])
+
+	return servicePort, true
+}
+
+type ONTPortNativeVlan struct {
+	ONTID      int    `json:"ont_id"`
+	PortID     int    `json:"port_id"`
+	PortType   string `json:"port_type"`
+	NativeVlan int    `json:"native_vlan"`
+	Priority   int    `json:"priority"`
+}
+
+// ParseONTPortNativeVlans parses "display ont port attribute P ONTID eth".
+func ParseONTPortNativeVlans(output string) ([]ONTPortNativeVlan, error) {
+	results := make([]ONTPortNativeVlan, 0)
+	vlanColumn, priorityColumn := -1, -1
+
+	for _, line := range strings.Split(output, "\n") {
+		err := parseFailure(line)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
 		}
+
+		if vlanColumn < 0 {
+			vlanColumn = columnIndex(fields, "Native")
+			priorityColumn = columnIndex(fields, "Priority")
+			continue
+		}
+
+		if len(fields) < 3 || len(fields) <= vlanColumn || !isNumber(fields[0]) || !isNumber(fields[1]) {
+			continue
+		}
+
+		ontID, _ := strconv.Atoi(fields[0])
+		portID, _ := strconv.Atoi(fields[1])
+		entry := ONTPortNativeVlan{
+			ONTID:      ontID,
+			PortID:     portID,
+			PortType:   strings.ToLower(fields[2]),
+			NativeVlan: parseIntOrZero(fields[vlanColumn]),
+		}
+		if priorityColumn >= 0 && priorityColumn < len(fields) {
+			entry.Priority = parseIntOrZero(fields[priorityColumn])
+		}
+
+		results = append(results, entry)
+	}
+
+	if vlanColumn < 0 {
+		return nil, fmt.Errorf("native VLAN column not found in command output")
 	}
 
 	return results, nil
 }
 
+var (
+	fspRegexp = regexp.MustCompile(`(\d+)\s*/\s*(\d+)\s*/\s*(\d+)`)
+	macRegexp = regexp.MustCompile(`^[0-9a-f]{12}$`)
+)
+
+// parseMAC accepts MAC addresses in Huawei ("0011-2233-4455"), colon or
+// dash separated and bare notation and returns them as "00:11:22:33:44:55".
+func parseMAC(mac string) (string, error) {
+	hex := strings.ToLower(strings.NewReplacer("-", "", ":", "", ".", "").Replace(strings.TrimSpace(mac)))
+	if !macRegexp.MatchString(hex) {
+		return "", InvalidMACAddressError{}
+	}
+
+	parts := make([]string, 0, 6)
+	for i := 0; i < len(hex); i += 2 {
+		parts = append(parts, hex[i:i+2])
+	}
+	return strings.Join(parts, ":"), nil
+}
+
+func huaweiMAC(mac string) (string, error) {
+	normalized, err := parseMAC(mac)
+	if err != nil {
+		return "", err
+	}
+	hex := strings.ReplaceAll(normalized, ":", "")
+	return hex[0:4] + "-" + hex[4:8] + "-" + hex[8:12], nil
+}
+
+// splitKeyValue splits a "Key : value" line. The key is lower-cased and
+// stripped of spaces, dashes and underscores.
+func splitKeyValue(line string) (string, string, bool) {
+	parts := strings.SplitN(line, ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	key := strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(parts[0]))
+	if key == "" {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(parts[1]), true
+}
+
 func getFrameSlotPortFromFSP(fsp string) (int, int, int, error) {
 	parts := strings.Split(fsp, "/")
 	frame, err := strconv.Atoi(parts[0])
@@ -335,3 +850,34 @@ func parseDateTime(dateTimeStr string) string {
 
 	return parsedTime.Format("2006-01-02 15:04:05-07:00")
 }
+
+func columnIndex(header []string, name string) int {
+	for i, field := range header {
+		if field == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func isNumber(value string) bool {
+	_, err := strconv.Atoi(value)
+	return err == nil
+}
+
+func parseIntOrZero(value string) int {
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return number
+}
+
+func isReading(values []string) bool {
+	for _, value := range values {
+		if value != "-" && parseOptionalFloat(value) == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,138 @@
+package sshclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func readTestdata(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestParseServicePorts(t *testing.T) {
+	servicePorts, err := ParseServicePorts(readTestdata(t, "service_port.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(servicePorts) != 2 {
+		t.Fatalf("expected 2 service ports, got %d", len(servicePorts))
+	}
+
+	expected := ServicePort{
+		Index:          13,
+		Vlan:           200,
+		VlanAttr:       "common",
+		PortType:       "gpon",
+		FSP:            "0/1/3",
+		ONTID:          7,
+		GemPort:        21,
+		FlowType:       "vlan",
+		FlowParameter:  "30",
+		RxTrafficTable: 10,
+		TxTrafficTable: 10,
+		State:          "down",
+	}
+	if servicePorts[1] != expected {
+		t.Fatalf("got %+v, want %+v", servicePorts[1], expected)
+	}
+}
+
+func TestParseServicePortLineWithoutFlowParameter(t *testing.T) {
+	servicePort, ok := ParseServicePortLine("      14  300 common   gpon 0/1/3    7    22    other-all  10   10   up")
+	if !ok {
+		t.Fatal("expected the row to be parsed")
+	}
+	expected := ServicePort{Index: 14, Vlan: 300, VlanAttr: "common", PortType: "gpon", FSP: "0/1/3", ONTID: 7, GemPort: 22, FlowType: "other-all", RxTrafficTable: 10, TxTrafficTable: 10, State: "up"}
+	if servicePort != expected {
+		t.Fatalf("got %+v, want %+v", servicePort, expected)
+	}
+}
+
+func TestParseONTPortNativeVlans(t *testing.T) {
+	nativeVlans, err := ParseONTPortNativeVlans(readTestdata(t, "ont_port_attribute_eth.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(nativeVlans) != 4 {
+		t.Fatalf("expected 4 ports, got %d", len(nativeVlans))
+	}
+	expected := ONTPortNativeVlan{ONTID: 1, PortID: 2, PortType: "eth", NativeVlan: 300, Priority: 5}
+	if nativeVlans[1] != expected {
+		t.Fatalf("got %+v, want %+v", nativeVlans[1], expected)
+	}
+}
+
+func TestParseONTPortNativeVlansWithoutHeader(t *testing.T) {
+	_, err := ParseONTPortNativeVlans("  1    1    ETH  -       20      0\n(config-if-gpon-0/1)#")
+	if err == nil {
+		t.Fatal("expected an error for output without a Native column")
+	}
+}
+
+func TestParseONTOpticalReadingsPort(t *testing.T) {
+	readings, err := ParseONTOpticalReadings(readTestdata(t, "ont_optical_info_port.txt"), "0/1/3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 3 {
+		t.Fatalf("expected 3 readings, got %d", len(readings))
+	}
+	if readings[1].FSP != "0/1/3" || readings[1].ONTID != 1 || readings[1].RxPower != nil || readings[1].Voltage != nil {
+		t.Fatalf("expected an offline ONT without readings, got %+v", readings[1])
+	}
+	reading := readings[2]
+	if reading.ONTID != 2 || *reading.RxPower != -19.02 || *reading.TxPower != 2.11 || *reading.OLTRxPower != -22.10 ||
+		*reading.Temperature != 51 || *reading.Voltage != 3.3 || *reading.BiasCurrent != 13 {
+		t.Fatalf("unexpected reading %+v", reading)
+	}
+}
+
+func TestParseONTOpticalReadingsBoard(t *testing.T) {
+	readings, err := ParseONTOpticalReadings(readTestdata(t, "ont_optical_info_board.txt"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(readings) != 3 {
+		t.Fatalf("expected 3 readings, got %d", len(readings))
+	}
+	if readings[1].FSP != "0/1/0" || readings[1].ONTID != 5 || readings[2].FSP != "0/1/1" || readings[2].ONTID != 2 {
+		t.Fatalf("unexpected readings %+v", readings)
+	}
+}
+
+func TestParseONTVersions(t *testing.T) {
+	versions, err := ParseONTVersions(readTestdata(t, "ont_version.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("expected 2 versions, got %d", len(versions))
+	}
+	expected := ONTVersion{
+		FSP:                    "0/1/3",
+		ONTID:                  "1",
+		VendorID:               "HWTC",
+		ONTVersion:             "5D7.A",
+		ProductID:              "0",
+		EquipmentID:            "EG8145V5",
+		MainSoftwareVersion:    "V5R020C00S115",
+		StandbySoftwareVersion: "V5R019C10S120",
+		ProductDescription:     "EchoLife EG8145V5 GPON Terminal (CLASS B+/PRODUCT ID:2150084211)",
+	}
+	if versions[1] != expected {
+		t.Fatalf("got %+v, want %+v", versions[1], expected)
+	}
+}
+
+func TestParseONTVersionNotFound(t *testing.T) {
+	_, err := ParseONTVersion("  The required ONT does not exist\n(config)#")
+	if _, ok := err.(NotFoundError); !ok {
+		t.Fatalf("expected NotFoundError, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package sshclient
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type PONPortState struct {
+	FSP                    string   `json:"fsp"`
+	PortType               string   `json:"port_type"`
+	PortState              string   `json:"port_state"`
+	LaserState             string   `json:"laser_state"`
+	OpticalModuleStatus    string   `json:"optical_module_status"`
+	AvailableBandwidthKbps *int     `json:"available_bandwidth_kbps"`
+	ONTCount               *int     `json:"ont_count"`
+	OnlineONTCount         *int     `json:"online_ont_count"`
+	ModuleVendor           string   `json:"module_vendor"`
+	ModulePartNumber       string   `json:"module_part_number"`
+	ModuleSerialNumber     string   `json:"module_serial_number"`
+	Wavelength             *int     `json:"wavelength_nm"`
+	TxPower                *float64 `json:"tx_power_dbm"`
+	Temperature            *float64 `json:"temperature_c"`
+	BiasCurrent            *float64 `json:"bias_current_ma"`
+	Voltage                *float64 `json:"voltage_v"`
+	MaxDistance            *float64 `json:"max_distance_km"`
+	FiberType              string   `json:"fiber_type"`
+	RogueONT               string   `json:"rogue_ont"`
+}
+
+func (p *PONPortState) GetFrameSlotPort() (int, int, int, error) {
+	return getFrameSlotPortFromFSP(p.FSP)
+}
+
+// GetPONPortState merges "display port state P", "display port info P" and
+// the ONT counts of "display ont info summary P", run from interface GPON
+// mode.
+func (c *CommandExecutor) GetPONPortState(port int) (*PONPortState, error) {
+	if c.ExecutorContext.Level != 3 {
+		return nil, fmt.Errorf("not in interface gpon mode")
+	}
+
+	prompt := fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot)
+	state := &PONPortState{FSP: fmt.Sprintf("%d/%d/%d", c.ExecutorContext.Frame, c.ExecutorContext.Slot, port)}
+
+	output, err := c.ExecuteCommand(fmt.Sprintf("display port state %d", port), prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	err = ParsePONPortState(output, state)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err = c.ExecuteCommand(fmt.Sprintf("display port info %d", port), prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	err = ParsePONPortState(output, state)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err = c.ExecuteCommand(fmt.Sprintf("display ont info summary %d", port), prompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	summary, err := ParseONTSummary(output)
+	if err != nil {
+		return nil, err
+	}
+	if summary.FSP != "" {
+		state.ONTCount = &summary.Total
+		state.OnlineONTCount = &summary.Online
+	}
+
+	return state, nil
+}
+
+var alignedKeyValueRegexp = regexp.MustCompile(`^(.*?\S)\s*(?::\s*|\s{2,})(\S.*)$`)
+
+// ParsePONPortState fills state from "display port state" or "display port
+// info" output.
+func ParsePONPortState(output string, state *PONPortState) error {
+	for _, line := range strings.Split(output, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return err
+		}
+
+		match := alignedKeyValueRegexp.FindStringSubmatch(trimmedLine)
+		if match == nil {
+			continue
+		}
+		key := strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(match[1]))
+		value := strings.TrimSpace(match[2])
+
+		switch key {
+		case "f/s/p":
+			state.FSP = fspRegexp.ReplaceAllString(value, "$1/$2/$3")
+		case "porttype":
+			state.PortType = value
+		case "portstate":
+			state.PortState = value
+		case "laserstate":
+			state.LaserState = value
+		case "opticalmodulestatus":
+			state.OpticalModuleStatus = value
+		case "availablebandwidth(kbps)":
+			state.AvailableBandwidthKbps = parseOptionalInt(value)
+		case "vendorname":
+			state.ModuleVendor = value
+		case "vendorpn":
+			state.ModulePartNumber = value
+		case "vendorsn":
+			state.ModuleSerialNumber = value
+		case "wavelength(nm)":
+			state.Wavelength = parseOptionalInt(value)
+		case "txpower(dbm)":
+			state.TxPower = parseOptionalFloat(value)
+		case "temperature(c)":
+			state.Temperature = parseOptionalFloat(value)
+		case "txbiascurrent(ma)":
+			state.BiasCurrent = parseOptionalFloat(value)
+		case "supplyvoltage(v)":
+			state.Voltage = parseOptionalFloat(value)
+		case "maxdistance(km)":
+			state.MaxDistance = parseOptionalFloat(value)
+		case "fibertype":
+			state.FiberType = value
+		case "illegalrogueont":
+			state.RogueONT = value
+		}
+	}
+
+	return nil
+}
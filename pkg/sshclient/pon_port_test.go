@@ -0,0 +1,33 @@
+package sshclient
+
+import "testing"
+
+func TestGetPONPortState(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 3, Frame: 0, Slot: 1}, map[string]string{
+		"display port state 0":       readTestdata(t, "port_state.txt"),
+		"display port info 0":        readTestdata(t, "port_info.txt"),
+		"display ont info summary 0": readTestdata(t, "ont_info_summary_port.txt"),
+	})
+
+	state, err := executor.GetPONPortState(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, "display port state 0", "display port info 0", "display ont info summary 0")
+
+	if state.FSP != "0/1/0" || state.PortType != "GPON" || state.PortState != "Online" || state.LaserState != "Normal" || state.OpticalModuleStatus != "Online" {
+		t.Fatalf("unexpected port state %+v", state)
+	}
+	if state.ModuleVendor != "HUAWEI" || state.ModulePartNumber != "LTE3680M-BC+" || state.ModuleSerialNumber != "032BKD10G4001234" {
+		t.Fatalf("unexpected optical module %+v", state)
+	}
+	if *state.AvailableBandwidthKbps != 1238110 || *state.Wavelength != 1490 || *state.TxPower != 3.01 || *state.Voltage != 3.26 || *state.MaxDistance != 20 {
+		t.Fatalf("unexpected readings %+v", state)
+	}
+	if *state.ONTCount != 2 || *state.OnlineONTCount != 1 {
+		t.Fatalf("expected 2 ONTs with 1 online, got %d and %d", *state.ONTCount, *state.OnlineONTCount)
+	}
+	if state.FiberType != "Single Mode" || state.RogueONT != "Inexistent" {
+		t.Fatalf("unexpected fiber %q and rogue ONT %q", state.FiberType, state.RogueONT)
+	}
+}
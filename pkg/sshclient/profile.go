@@ -0,0 +1,824 @@
+package sshclient
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PortCountAdaptive is the port count of a service profile whose ports are
+// adapted to the ONT ("adaptive").
+const PortCountAdaptive = -1
+
+type ProfileSummary struct {
+	ID           int    `json:"id"`
+	Name         string `json:"name"`
+	BindingTimes int    `json:"binding_times"`
+}
+
+type LineProfile struct {
+	ID           int                  `json:"id"`
+	Name         string               `json:"name"`
+	AccessType   string               `json:"access_type"`
+	MappingMode  string               `json:"mapping_mode"`
+	BindingTimes int                  `json:"binding_times"`
+	TConts       []LineProfileTCont   `json:"tconts"`
+	GemPorts     []LineProfileGemPort `json:"gem_ports"`
+}
+
+type LineProfileTCont struct {
+	ID           int `json:"id"`
+	DBAProfileID int `json:"dba_profile_id"`
+}
+
+type LineProfileGemPort struct {
+	Index       int          `json:"index"`
+	TCont       int          `json:"tcont"`
+	ServiceType string       `json:"service_type"`
+	Encrypt     bool         `json:"encrypt"`
+	Mappings    []GemMapping `json:"mappings"`
+}
+
+type GemMapping struct {
+	Index    int    `json:"index"`
+	Vlan     *int   `json:"vlan"`
+	Priority *int   `json:"priority"`
+	PortType string `json:"port_type"`
+	PortID   *int   `json:"port_id"`
+}
+
+type ServiceProfile struct {
+	ID           int                        `json:"id"`
+	Name         string                     `json:"name"`
+	AccessType   string                     `json:"access_type"`
+	BindingTimes int                        `json:"binding_times"`
+	PortCounts   map[ONTPortType]int        `json:"port_counts"`
+	NativeVlans  []ServiceProfileNativeVlan `json:"native_vlans"`
+	PortVlans    []ServiceProfilePortVlan   `json:"port_vlans"`
+}
+
+type ServiceProfileNativeVlan struct {
+	Port     ONTPort `json:"port"`
+	Vlan     int     `json:"vlan"`
+	Priority *int    `json:"priority"`
+}
+
+type PortVlanMode string
+
+const (
+	PortVlanTranslation PortVlanMode = "translation"
+	PortVlanQinQ        PortVlanMode = "q-in-q"
+	PortVlanTransparent PortVlanMode = "transparent"
+)
+
+type ServiceProfilePortVlan struct {
+	Port     ONTPort      `json:"port"`
+	Mode     PortVlanMode `json:"mode"`
+	Vlan     int          `json:"vlan"`
+	UserVlan *int         `json:"user_vlan"`
+}
+
+type GemMappingMode string
+
+const (
+	GemMappingVlan             GemMappingMode = "vlan"
+	GemMappingPriority         GemMappingMode = "priority"
+	GemMappingVlanPriority     GemMappingMode = "vlan-priority"
+	GemMappingPort             GemMappingMode = "port"
+	GemMappingPortVlan         GemMappingMode = "port-vlan"
+	GemMappingPortPriority     GemMappingMode = "port-priority"
+	GemMappingPortVlanPriority GemMappingMode = "port-vlan-priority"
+)
+
+type TContSpec struct {
+	ID             int
+	DBAProfileID   *int
+	DBAProfileName string
+}
+
+type GemPortSpec struct {
+	Index   int
+	TCont   int
+	Encrypt bool
+}
+
+// GemMappingSpec maps traffic to a GEM port by ONT port, VLAN and/or
+// 802.1p priority, depending on the profile's mapping mode.
+type GemMappingSpec struct {
+	GemIndex int
+	Index    int
+	Port     *ONTPort
+	Vlan     *int
+	Priority *int
+}
+
+// LineProfileRequest describes the changes to make to an "ont-lineprofile
+// gpon" profile. Removals run before additions, so an entry can be
+// redefined in a single call.
+type LineProfileRequest struct {
+	ProfileID         int
+	ProfileName       string
+	MappingMode       GemMappingMode
+	TConts            []TContSpec
+	GemPorts          []GemPortSpec
+	GemMappings       []GemMappingSpec
+	RemoveGemMappings []GemMappingSpec
+	RemoveGemPorts    []int
+	RemoveTConts      []int
+}
+
+func (r LineProfileRequest) Validate() error {
+	if r.ProfileID < 0 {
+		return InvalidRequestError{Field: "ProfileID", Reason: "must not be negative"}
+	}
+	if err := validateName("ProfileName", r.ProfileName); err != nil {
+		return err
+	}
+
+	switch r.MappingMode {
+	case "", GemMappingVlan, GemMappingPriority, GemMappingVlanPriority, GemMappingPort, GemMappingPortVlan, GemMappingPortPriority, GemMappingPortVlanPriority:
+	default:
+		return InvalidRequestError{Field: "MappingMode", Reason: fmt.Sprintf("unsupported value %q", r.MappingMode)}
+	}
+
+	for _, tcont := range r.TConts {
+		if err := validateTCont("TConts", tcont.ID); err != nil {
+			return err
+		}
+		if tcont.DBAProfileID == nil && tcont.DBAProfileName == "" {
+			return InvalidRequestError{Field: "TConts", Reason: "a DBA profile ID or name is required"}
+		}
+		if tcont.DBAProfileID != nil && tcont.DBAProfileName != "" {
+			return InvalidRequestError{Field: "TConts", Reason: "DBA profile ID and name cannot be combined"}
+		}
+		if err := validateName("TConts", tcont.DBAProfileName); err != nil {
+			return err
+		}
+	}
+	for _, id := range r.RemoveTConts {
+		if err := validateTCont("RemoveTConts", id); err != nil {
+			return err
+		}
+	}
+
+	for _, gem := range r.GemPorts {
+		if gem.Index < 0 {
+			return InvalidRequestError{Field: "GemPorts", Reason: "index must not be negative"}
+		}
+		if err := validateTCont("GemPorts", gem.TCont); err != nil {
+			return err
+		}
+	}
+
+	for _, mapping := range r.GemMappings {
+		if err := validateGemMapping("GemMappings", mapping); err != nil {
+			return err
+		}
+		if mapping.Port == nil && mapping.Vlan == nil && mapping.Priority == nil {
+			return InvalidRequestError{Field: "GemMappings", Reason: "a port, VLAN or priority is required"}
+		}
+		if mapping.Port != nil {
+			if err := mapping.Port.Validate(); err != nil {
+				return err
+			}
+		}
+		if mapping.Vlan != nil {
+			if err := validateVlan("GemMappings", *mapping.Vlan); err != nil {
+				return err
+			}
+		}
+		if mapping.Priority != nil {
+			if err := validatePriority("GemMappings", *mapping.Priority); err != nil {
+				return err
+			}
+		}
+	}
+	for _, mapping := range r.RemoveGemMappings {
+		if err := validateGemMapping("RemoveGemMappings", mapping); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r LineProfileRequest) commands() ([]string, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	commands := make([]string, 0)
+	for _, mapping := range r.RemoveGemMappings {
+		commands = append(commands, fmt.Sprintf("undo gem mapping %d %d", mapping.GemIndex, mapping.Index))
+	}
+	for _, index := range r.RemoveGemPorts {
+		commands = append(commands, fmt.Sprintf("gem delete %d", index))
+	}
+	for _, id := range r.RemoveTConts {
+		commands = append(commands, fmt.Sprintf("undo tcont %d", id))
+	}
+
+	if r.MappingMode != "" {
+		commands = append(commands, "mapping-mode "+string(r.MappingMode))
+	}
+	for _, tcont := range r.TConts {
+		if tcont.DBAProfileID != nil {
+			commands = append(commands, fmt.Sprintf("tcont %d dba-profile-id %d", tcont.ID, *tcont.DBAProfileID))
+		} else {
+			commands = append(commands, fmt.Sprintf("tcont %d dba-profile-name %s", tcont.ID, quote(tcont.DBAProfileName)))
+		}
+	}
+	for _, gem := range r.GemPorts {
+		command := fmt.Sprintf("gem add %d eth tcont %d", gem.Index, gem.TCont)
+		if gem.Encrypt {
+			command += " encrypt on"
+		}
+		commands = append(commands, command)
+	}
+	for _, mapping := range r.GemMappings {
+		parts := []string{"gem mapping", fmt.Sprint(mapping.GemIndex), fmt.Sprint(mapping.Index)}
+		if mapping.Port != nil {
+			parts = append(parts, mapping.Port.String())
+		}
+		if mapping.Vlan != nil {
+			parts = append(parts, "vlan", fmt.Sprint(*mapping.Vlan))
+		}
+		if mapping.Priority != nil {
+			parts = append(parts, "priority", fmt.Sprint(*mapping.Priority))
+		}
+		commands = append(commands, strings.Join(parts, " "))
+	}
+
+	return commands, nil
+}
+
+func validateTCont(field string, id int) error {
+	if id < 0 || id > 127 {
+		return InvalidRequestError{Field: field, Reason: "T-CONT ID must be between 0 and 127"}
+	}
+	return nil
+}
+
+func validateGemMapping(field string, mapping GemMappingSpec) error {
+	if mapping.GemIndex < 0 {
+		return InvalidRequestError{Field: field, Reason: "GEM index must not be negative"}
+	}
+	if mapping.Index < 0 || mapping.Index > 7 {
+		return InvalidRequestError{Field: field, Reason: "mapping index must be between 0 and 7"}
+	}
+	return nil
+}
+
+// ServiceProfileRequest describes the changes to make to an "ont-srvprofile
+// gpon" profile. Nil port counts are left unchanged; PortCountAdaptive
+// selects adaptive mode.
+type ServiceProfileRequest struct {
+	ProfileID         int
+	ProfileName       string
+	ETHPorts          *int
+	POTSPorts         *int
+	CATVPorts         *int
+	PortVlans         []ServiceProfilePortVlan
+	NativeVlans       []ServiceProfileNativeVlan
+	RemovePortVlans   []ServiceProfilePortVlan
+	RemoveNativeVlans []ONTPort
+}
+
+func (r ServiceProfileRequest) Validate() error {
+	if r.ProfileID < 0 {
+		return InvalidRequestError{Field: "ProfileID", Reason: "must not be negative"}
+	}
+	if err := validateName("ProfileName", r.ProfileName); err != nil {
+		return err
+	}
+
+	for field, count := range map[string]*int{"ETHPorts": r.ETHPorts, "POTSPorts": r.POTSPorts, "CATVPorts": r.CATVPorts} {
+		if count != nil && *count < PortCountAdaptive {
+			return InvalidRequestError{Field: field, Reason: "must not be negative"}
+		}
+	}
+
+	for _, portVlans := range [][]ServiceProfilePortVlan{r.PortVlans, r.RemovePortVlans} {
+		for _, portVlan := range portVlans {
+			if err := portVlan.validate(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, nativeVlan := range r.NativeVlans {
+		if err := nativeVlan.Port.Validate(); err != nil {
+			return err
+		}
+		if err := validateVlan("NativeVlans", nativeVlan.Vlan); err != nil {
+			return err
+		}
+		if nativeVlan.Priority != nil {
+			if err := validatePriority("NativeVlans", *nativeVlan.Priority); err != nil {
+				return err
+			}
+		}
+	}
+	for _, port := range r.RemoveNativeVlans {
+		if err := port.Validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r ServiceProfileRequest) commands() ([]string, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	commands := make([]string, 0)
+	for _, portVlan := range r.RemovePortVlans {
+		commands = append(commands, "undo "+portVlan.argument())
+	}
+	for _, port := range r.RemoveNativeVlans {
+		commands = append(commands, "undo port native-vlan "+port.String())
+	}
+
+	ports := []string{"ont-port"}
+	for _, count := range []struct {
+		portType ONTPortType
+		value    *int
+	}{{ONTPortETH, r.ETHPorts}, {ONTPortPOTS, r.POTSPorts}, {ONTPortCATV, r.CATVPorts}} {
+		if count.value == nil {
+			continue
+		}
+		if *count.value == PortCountAdaptive {
+			ports = append(ports, string(count.portType), "adaptive")
+		} else {
+			ports = append(ports, string(count.portType), fmt.Sprint(*count.value))
+		}
+	}
+	if len(ports) > 1 {
+		commands = append(commands, strings.Join(ports, " "))
+	}
+
+	for _, portVlan := range r.PortVlans {
+		commands = append(commands, portVlan.argument())
+	}
+	for _, nativeVlan := range r.NativeVlans {
+		command := fmt.Sprintf("port native-vlan %s vlan %d", nativeVlan.Port, nativeVlan.Vlan)
+		if nativeVlan.Priority != nil {
+			command += fmt.Sprintf(" priority %d", *nativeVlan.Priority)
+		}
+		commands = append(commands, command)
+	}
+
+	return commands, nil
+}
+
+func (v ServiceProfilePortVlan) validate() error {
+	if err := v.Port.Validate(); err != nil {
+		return err
+	}
+
+	switch v.Mode {
+	case PortVlanTransparent:
+		return nil
+	case PortVlanTranslation:
+	case PortVlanQinQ:
+		if v.UserVlan == nil {
+			return InvalidRequestError{Field: "UserVlan", Reason: "is required for q-in-q"}
+		}
+	default:
+		return InvalidRequestError{Field: "Mode", Reason: fmt.Sprintf("unsupported value %q", v.Mode)}
+	}
+
+	if err := validateVlan("Vlan", v.Vlan); err != nil {
+		return err
+	}
+	if v.UserVlan != nil {
+		return validateVlan("UserVlan", *v.UserVlan)
+	}
+	return nil
+}
+
+func (v ServiceProfilePortVlan) argument() string {
+	switch {
+	case v.Mode == PortVlanTransparent:
+		return fmt.Sprintf("port vlan %s transparent", v.Port)
+	case v.UserVlan != nil:
+		return fmt.Sprintf("port vlan %s %s %d user-vlan %d", v.Port, v.Mode, v.Vlan, *v.UserVlan)
+	default:
+		return fmt.Sprintf("port vlan %s %d", v.Port, v.Vlan)
+	}
+}
+
+func (c *CommandExecutor) GetLineProfiles() ([]ProfileSummary, error) {
+	return c.getProfiles("display ont-lineprofile gpon all")
+}
+
+func (c *CommandExecutor) GetLineProfile(id int) (*LineProfile, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont-lineprofile gpon profile-id %d", id), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseLineProfile(output)
+}
+
+// CreateLineProfile adds a line profile and fails if the ID is taken, so an
+// existing profile bound to live ONTs is never changed by accident.
+func (c *CommandExecutor) CreateLineProfile(req LineProfileRequest) error {
+	commands, err := req.commands()
+	if err != nil {
+		return err
+	}
+	if _, err := c.GetLineProfile(req.ProfileID); err == nil {
+		return fmt.Errorf("line profile %d already exists", req.ProfileID)
+	} else if _, ok := err.(ProfileNotFoundError); !ok {
+		return err
+	}
+
+	enter := fmt.Sprintf("ont-lineprofile gpon profile-id %d", req.ProfileID)
+	if req.ProfileName != "" {
+		enter += " profile-name " + quote(req.ProfileName)
+	}
+	undo := fmt.Sprintf("undo ont-lineprofile gpon profile-id %d", req.ProfileID)
+	return c.configureProfile(enter, fmt.Sprintf("(config-gpon-lineprofile-%d)#", req.ProfileID), commands, undo)
+}
+
+// ModifyLineProfile applies req to an existing line profile. ProfileName is
+// ignored; the OLT does not rename profiles in place.
+func (c *CommandExecutor) ModifyLineProfile(req LineProfileRequest) error {
+	commands, err := req.commands()
+	if err != nil {
+		return err
+	}
+	if _, err := c.GetLineProfile(req.ProfileID); err != nil {
+		return err
+	}
+
+	enter := fmt.Sprintf("ont-lineprofile gpon profile-id %d", req.ProfileID)
+	return c.configureProfile(enter, fmt.Sprintf("(config-gpon-lineprofile-%d)#", req.ProfileID), commands, "")
+}
+
+func (c *CommandExecutor) DeleteLineProfile(id int) error {
+	return c.deleteProfile(fmt.Sprintf("undo ont-lineprofile gpon profile-id %d", id))
+}
+
+func (c *CommandExecutor) GetServiceProfiles() ([]ProfileSummary, error) {
+	return c.getProfiles("display ont-srvprofile gpon all")
+}
+
+func (c *CommandExecutor) GetServiceProfile(id int) (*ServiceProfile, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont-srvprofile gpon profile-id %d", id), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseServiceProfile(output)
+}
+
+func (c *CommandExecutor) CreateServiceProfile(req ServiceProfileRequest) error {
+	commands, err := req.commands()
+	if err != nil {
+		return err
+	}
+	if _, err := c.GetServiceProfile(req.ProfileID); err == nil {
+		return fmt.Errorf("service profile %d already exists", req.ProfileID)
+	} else if _, ok := err.(ProfileNotFoundError); !ok {
+		return err
+	}
+
+	enter := fmt.Sprintf("ont-srvprofile gpon profile-id %d", req.ProfileID)
+	if req.ProfileName != "" {
+		enter += " profile-name " + quote(req.ProfileName)
+	}
+	undo := fmt.Sprintf("undo ont-srvprofile gpon profile-id %d", req.ProfileID)
+	return c.configureProfile(enter, fmt.Sprintf("(config-gpon-srvprofile-%d)#", req.ProfileID), commands, undo)
+}
+
+func (c *CommandExecutor) ModifyServiceProfile(req ServiceProfileRequest) error {
+	commands, err := req.commands()
+	if err != nil {
+		return err
+	}
+	if _, err := c.GetServiceProfile(req.ProfileID); err != nil {
+		return err
+	}
+
+	enter := fmt.Sprintf("ont-srvprofile gpon profile-id %d", req.ProfileID)
+	return c.configureProfile(enter, fmt.Sprintf("(config-gpon-srvprofile-%d)#", req.ProfileID), commands, "")
+}
+
+func (c *CommandExecutor) DeleteServiceProfile(id int) error {
+	return c.deleteProfile(fmt.Sprintf("undo ont-srvprofile gpon profile-id %d", id))
+}
+
+func (c *CommandExecutor) getProfiles(command string) ([]ProfileSummary, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(command, "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseProfileSummaries(output)
+}
+
+func (c *CommandExecutor) deleteProfile(command string) error {
+	if c.ExecutorContext.Level != 2 {
+		return fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(command, "(config)#")
+	if err != nil {
+		return fmt.Errorf("failed to run command: %v", err)
+	}
+	return parseLinesFailure(strings.Split(output, "\n"))
+}
+
+// configureProfile enters a profile sub-mode, runs commands and commits
+// them. The executor is back in config mode when it returns; if a command
+// fails the sub-mode is left without committing and, when undo is set, the
+// profile created by enter is removed again.
+func (c *CommandExecutor) configureProfile(enter, prompt string, commands []string, undo string) error {
+	if c.ExecutorContext.Level != 2 {
+		return fmt.Errorf("not in config mode")
+	}
+
+	output, err := c.executeCommand(enter, c.FilterUnsolicited, prompt, "(config)#")
+	if err != nil {
+		return fmt.Errorf("failed to run command: %v", err)
+	}
+	if !strings.Contains(output, prompt) {
+		if err := parseLinesFailure(strings.Split(output, "\n")); err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to enter %s", strings.TrimSuffix(strings.TrimPrefix(prompt, "("), ")#"))
+	}
+
+	for _, command := range append(commands, "commit") {
+		output, err := c.ExecuteCommand(command, prompt)
+		if err != nil {
+			return fmt.Errorf("failed to run command: %v", err)
+		}
+		err = parseLinesFailure(strings.Split(output, "\n"))
+		if err != nil {
+			if _, quitErr := c.ExecuteCommand("quit", "(config)#"); quitErr != nil {
+				return fmt.Errorf("failed to run command: %v", quitErr)
+			}
+			if undo != "" {
+				if undoErr := c.deleteProfile(undo); undoErr != nil {
+					return fmt.Errorf("%v; failed to remove the profile: %v", err, undoErr)
+				}
+			}
+			return err
+		}
+	}
+
+	_, err = c.ExecuteCommand("quit", "(config)#")
+	if err != nil {
+		return fmt.Errorf("failed to run command: %v", err)
+	}
+	return nil
+}
+
+// ParseProfileSummaries parses the "display ont-lineprofile gpon all" and
+// "display ont-srvprofile gpon all" tables.
+func ParseProfileSummaries(output string) ([]ProfileSummary, error) {
+	results := make([]ProfileSummary, 0)
+
+	for _, line := range strings.Split(output, "\n") {
+		err := parseFailure(line)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 3 || !isNumber(fields[0]) || !isNumber(fields[len(fields)-1]) {
+			continue
+		}
+
+		id, _ := strconv.Atoi(fields[0])
+		bindingTimes, _ := strconv.Atoi(fields[len(fields)-1])
+		results = append(results, ProfileSummary{
+			ID:           id,
+			Name:         strings.Join(fields[1:len(fields)-1], " "),
+			BindingTimes: bindingTimes,
+		})
+	}
+
+	return results, nil
+}
+
+var (
+	tcontRegexp    = regexp.MustCompile(`<T-CONT\s+(\d+)>\s+DBA Profile-ID\s*:\s*(\d+)`)
+	gemIndexRegexp = regexp.MustCompile(`<Gem Index\s+(\d+)>`)
+)
+
+// ParseLineProfile parses "display ont-lineprofile gpon profile-id N". GEM
+// ports belong to the T-CONT listed above them.
+func ParseLineProfile(output string) (*LineProfile, error) {
+	profile := &LineProfile{TConts: make([]LineProfileTCont, 0), GemPorts: make([]LineProfileGemPort, 0)}
+	found := false
+	tcont := -1
+	var gem *LineProfileGemPort
+
+	flush := func() {
+		if gem != nil {
+			profile.GemPorts = append(profile.GemPorts, *gem)
+			gem = nil
+		}
+	}
+
+	for _, line := range strings.Split(output, "\n") {
+		if err := parseProfileFailure(line); err != nil {
+			return nil, err
+		}
+		trimmedLine := strings.TrimSpace(line)
+
+		if match := tcontRegexp.FindStringSubmatch(trimmedLine); match != nil {
+			flush()
+			tcont, _ = strconv.Atoi(match[1])
+			dbaProfileID, _ := strconv.Atoi(match[2])
+			profile.TConts = append(profile.TConts, LineProfileTCont{ID: tcont, DBAProfileID: dbaProfileID})
+			continue
+		}
+		if match := gemIndexRegexp.FindStringSubmatch(trimmedLine); match != nil {
+			flush()
+			index, _ := strconv.Atoi(match[1])
+			gem = &LineProfileGemPort{Index: index, TCont: tcont, Mappings: make([]GemMapping, 0)}
+			continue
+		}
+		if gem != nil && strings.HasPrefix(trimmedLine, "|") {
+			for _, attribute := range strings.Split(trimmedLine, "|") {
+				key, value, ok := splitKeyValue(attribute)
+				if !ok {
+					continue
+				}
+				switch key {
+				case "servtype":
+					gem.ServiceType = value
+				case "encrypt":
+					gem.Encrypt = strings.EqualFold(value, "on")
+				}
+			}
+			continue
+		}
+
+		fields := strings.Fields(trimmedLine)
+		if gem != nil && len(fields) >= 5 && isNumber(fields[0]) {
+			index, _ := strconv.Atoi(fields[0])
+			mapping := GemMapping{
+				Index:    index,
+				Vlan:     parseOptionalInt(fields[1]),
+				Priority: parseOptionalInt(fields[2]),
+				PortID:   parseOptionalInt(fields[4]),
+			}
+			if fields[3] != "-" {
+				mapping.PortType = fields[3]
+			}
+			gem.Mappings = append(gem.Mappings, mapping)
+			continue
+		}
+
+		key, value, ok := splitKeyValue(trimmedLine)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "profileid":
+			profile.ID, _ = strconv.Atoi(value)
+			found = true
+		case "profilename":
+			profile.Name = value
+		case "accesstype":
+			profile.AccessType = value
+		case "mappingmode":
+			profile.MappingMode = strings.ToLower(value)
+		case "bindingtimes":
+			profile.BindingTimes, _ = strconv.Atoi(value)
+		}
+	}
+	flush()
+
+	if !found {
+		return nil, ProfileNotFoundError{}
+	}
+	return profile, nil
+}
+
+// ParseServiceProfile parses "display ont-srvprofile gpon profile-id N".
+// Rows are read through the header of the table they belong to.
+func ParseServiceProfile(output string) (*ServiceProfile, error) {
+	profile := &ServiceProfile{
+		PortCounts:  map[ONTPortType]int{},
+		NativeVlans: make([]ServiceProfileNativeVlan, 0),
+		PortVlans:   make([]ServiceProfilePortVlan, 0),
+	}
+	found := false
+	var header []string
+
+	for _, line := range strings.Split(output, "\n") {
+		if err := parseProfileFailure(line); err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(line)
+
+		if len(fields) > 1 && strings.EqualFold(fields[0], "Port-type") {
+			header = fields
+			continue
+		}
+		if header != nil && len(fields) > 1 && isProfilePortType(fields[0]) {
+			parseServiceProfileRow(profile, header, fields)
+			continue
+		}
+
+		key, value, ok := splitKeyValue(line)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "profileid":
+			profile.ID, _ = strconv.Atoi(value)
+			found = true
+		case "profilename":
+			profile.Name = value
+		case "accesstype":
+			profile.AccessType = value
+		case "bindingtimes":
+			profile.BindingTimes, _ = strconv.Atoi(value)
+		}
+	}
+
+	if !found {
+		return nil, ProfileNotFoundError{}
+	}
+	return profile, nil
+}
+
+func parseServiceProfileRow(profile *ServiceProfile, header, fields []string) {
+	portType := ONTPortType(strings.ToLower(fields[0]))
+	value := func(name string) string {
+		index := columnIndex(header, name)
+		if index < 0 || index >= len(fields) {
+			return "-"
+		}
+		return fields[index]
+	}
+
+	if columnIndex(header, "Port-number") >= 0 {
+		switch count := value("Port-number"); {
+		case strings.EqualFold(count, "adaptive"):
+			profile.PortCounts[portType] = PortCountAdaptive
+		case isNumber(count):
+			profile.PortCounts[portType] = parseIntOrZero(count)
+		}
+		return
+	}
+
+	portID := parseOptionalInt(value("Port-ID"))
+	if portID == nil {
+		return
+	}
+	port := ONTPort{Type: portType, Number: *portID}
+
+	if columnIndex(header, "Native-VLAN") >= 0 {
+		vlan := parseOptionalInt(value("Native-VLAN"))
+		if vlan != nil {
+			profile.NativeVlans = append(profile.NativeVlans, ServiceProfileNativeVlan{Port: port, Vlan: *vlan, Priority: parseOptionalInt(value("Priority"))})
+		}
+		return
+	}
+
+	if columnIndex(header, "Service-Type") >= 0 {
+		portVlan := ServiceProfilePortVlan{Port: port, Mode: PortVlanTranslation, UserVlan: parseOptionalInt(value("C-VLAN"))}
+		switch mode := strings.ToLower(value("Service-Type")); {
+		case strings.Contains(mode, "transparent"):
+			portVlan.Mode = PortVlanTransparent
+		case strings.Contains(mode, "q-in-q"):
+			portVlan.Mode = PortVlanQinQ
+		}
+		if vlan := parseOptionalInt(value("S-VLAN")); vlan != nil {
+			portVlan.Vlan = *vlan
+		}
+		profile.PortVlans = append(profile.PortVlans, portVlan)
+	}
+}
+
+func isProfilePortType(field string) bool {
+	switch strings.ToUpper(field) {
+	case "ETH", "POTS", "CATV", "VDSL", "TDM", "MOCA", "IPHOST", "VOIP":
+		return true
+	}
+	return false
+}
+
+func parseProfileFailure(line string) error {
+	if strings.Contains(line, "does not exist") {
+		return ProfileNotFoundError{}
+	}
+	return parseFailure(line)
+}
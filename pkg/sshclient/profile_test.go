@@ -0,0 +1,191 @@
+package sshclient
+
+import "testing"
+
+func TestParseLineProfile(t *testing.T) {
+	profile, err := ParseLineProfile(readTestdata(t, "ont_lineprofile.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.ID != 10 || profile.Name != "INTERNET" || profile.MappingMode != "vlan" || profile.BindingTimes != 5 {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+	if len(profile.TConts) != 2 || profile.TConts[1] != (LineProfileTCont{ID: 1, DBAProfileID: 10}) {
+		t.Fatalf("unexpected T-CONTs %+v", profile.TConts)
+	}
+	if len(profile.GemPorts) != 1 {
+		t.Fatalf("expected 1 GEM port, got %d", len(profile.GemPorts))
+	}
+	gem := profile.GemPorts[0]
+	if gem.Index != 1 || gem.TCont != 1 || gem.ServiceType != "ETH" || gem.Encrypt || len(gem.Mappings) != 2 {
+		t.Fatalf("unexpected GEM port %+v", gem)
+	}
+	mapping := gem.Mappings[1]
+	if *mapping.Vlan != 200 || *mapping.Priority != 5 || mapping.PortType != "ETH" || *mapping.PortID != 1 {
+		t.Fatalf("unexpected mapping %+v", mapping)
+	}
+	if gem.Mappings[0].PortType != "" || gem.Mappings[0].PortID != nil {
+		t.Fatalf("expected a VLAN-only mapping, got %+v", gem.Mappings[0])
+	}
+}
+
+func TestParseServiceProfile(t *testing.T) {
+	profile, err := ParseServiceProfile(readTestdata(t, "ont_srvprofile.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.ID != 20 || profile.Name != "HG8245H" || profile.BindingTimes != 12 {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+	if profile.PortCounts[ONTPortETH] != PortCountAdaptive || profile.PortCounts[ONTPortPOTS] != 2 || profile.PortCounts[ONTPortCATV] != 0 {
+		t.Fatalf("unexpected port counts %+v", profile.PortCounts)
+	}
+	if len(profile.NativeVlans) != 1 || profile.NativeVlans[0].Vlan != 100 || *profile.NativeVlans[0].Priority != 0 {
+		t.Fatalf("unexpected native VLANs %+v", profile.NativeVlans)
+	}
+	if len(profile.PortVlans) != 2 {
+		t.Fatalf("expected 2 port VLANs, got %d", len(profile.PortVlans))
+	}
+	translation := profile.PortVlans[0]
+	if translation.Mode != PortVlanTranslation || translation.Vlan != 200 || *translation.UserVlan != 30 {
+		t.Fatalf("unexpected port VLAN %+v", translation)
+	}
+	if profile.PortVlans[1].Mode != PortVlanTransparent || profile.PortVlans[1].UserVlan != nil {
+		t.Fatalf("unexpected port VLAN %+v", profile.PortVlans[1])
+	}
+}
+
+func TestParseProfileNotFound(t *testing.T) {
+	_, err := ParseLineProfile("  Failure: The profile does not exist\n(config)#")
+	if _, ok := err.(ProfileNotFoundError); !ok {
+		t.Fatalf("expected ProfileNotFoundError, got %v", err)
+	}
+}
+
+func TestCreateLineProfileRemovesProfileOnFailure(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display ont-lineprofile gpon profile-id 10":                   "  Failure: The profile does not exist\n(config)#",
+		"ont-lineprofile gpon profile-id 10 profile-name \"INTERNET\"": "(config-gpon-lineprofile-10)#",
+		"tcont 1 dba-profile-id 99":                                    "  Failure: The DBA profile does not exist\n(config-gpon-lineprofile-10)#",
+		"quit":                                                         "(config)#",
+		"undo ont-lineprofile gpon profile-id 10":                      "(config)#",
+	})
+
+	dbaProfileID := 99
+	err := executor.CreateLineProfile(LineProfileRequest{
+		ProfileID:   10,
+		ProfileName: "INTERNET",
+		TConts:      []TContSpec{{ID: 1, DBAProfileID: &dbaProfileID}},
+	})
+	if err == nil || err.Error() != "The DBA profile does not exist" {
+		t.Fatalf("expected the tcont failure, got %v", err)
+	}
+	assertCommands(t, terminal,
+		"display ont-lineprofile gpon profile-id 10",
+		"ont-lineprofile gpon profile-id 10 profile-name \"INTERNET\"",
+		"tcont 1 dba-profile-id 99",
+		"quit",
+		"undo ont-lineprofile gpon profile-id 10",
+	)
+}
+
+func TestModifyServiceProfileKeepsProfileOnFailure(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display ont-srvprofile gpon profile-id 20": readTestdata(t, "ont_srvprofile.txt"),
+		"ont-srvprofile gpon profile-id 20":         "(config-gpon-srvprofile-20)#",
+		"ont-port eth 4":                            "  Failure: The profile has been bound\n(config-gpon-srvprofile-20)#",
+		"quit":                                      "(config)#",
+	})
+
+	ports := 4
+	err := executor.ModifyServiceProfile(ServiceProfileRequest{ProfileID: 20, ETHPorts: &ports})
+	if err == nil {
+		t.Fatal("expected the ont-port failure")
+	}
+	assertCommands(t, terminal, "display ont-srvprofile gpon profile-id 20", "ont-srvprofile gpon profile-id 20", "ont-port eth 4", "quit")
+}
+
+func TestConfigureProfileNotEntered(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"ont-srvprofile gpon profile-id 20": "  Failure: The number of profiles reaches the maximum\n(config)#",
+	})
+
+	err := executor.configureProfile("ont-srvprofile gpon profile-id 20", "(config-gpon-srvprofile-20)#", []string{"ont-port eth 4"}, "undo ont-srvprofile gpon profile-id 20")
+	if err == nil || err.Error() != "The number of profiles reaches the maximum" {
+		t.Fatalf("expected the enter failure, got %v", err)
+	}
+	assertCommands(t, terminal, "ont-srvprofile gpon profile-id 20")
+}
+
+func TestCreateLineProfile(t *testing.T) {
+	prompt := "(config-gpon-lineprofile-10)#"
+	expected := []string{
+		"display ont-lineprofile gpon profile-id 10",
+		"ont-lineprofile gpon profile-id 10 profile-name \"INTERNET\"",
+		"mapping-mode vlan",
+		"tcont 1 dba-profile-name \"dba_100M\"",
+		"gem add 1 eth tcont 1 encrypt on",
+		"gem mapping 1 0 vlan 100",
+		"commit",
+		"quit",
+	}
+	replies := map[string]string{
+		expected[0]: "  Failure: The profile does not exist\n(config)#",
+		"quit":      "(config)#",
+	}
+	for _, command := range expected[1:7] {
+		replies[command] = prompt
+	}
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, replies)
+
+	vlan := 100
+	err := executor.CreateLineProfile(LineProfileRequest{
+		ProfileID:   10,
+		ProfileName: "INTERNET",
+		MappingMode: GemMappingVlan,
+		TConts:      []TContSpec{{ID: 1, DBAProfileName: "dba_100M"}},
+		GemPorts:    []GemPortSpec{{Index: 1, TCont: 1, Encrypt: true}},
+		GemMappings: []GemMappingSpec{{GemIndex: 1, Index: 0, Vlan: &vlan}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, expected...)
+}
+
+func TestCreateServiceProfile(t *testing.T) {
+	prompt := "(config-gpon-srvprofile-20)#"
+	expected := []string{
+		"display ont-srvprofile gpon profile-id 20",
+		"ont-srvprofile gpon profile-id 20 profile-name \"BRIDGE\"",
+		"ont-port eth adaptive pots 2 catv 0",
+		"port vlan eth 1 translation 200 user-vlan 30",
+		"port native-vlan eth 1 vlan 100 priority 0",
+		"commit",
+		"quit",
+	}
+	replies := map[string]string{
+		expected[0]: "  Failure: The profile does not exist\n(config)#",
+		"quit":      "(config)#",
+	}
+	for _, command := range expected[1:6] {
+		replies[command] = prompt
+	}
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, replies)
+
+	eth, pots, catv, userVlan, priority := PortCountAdaptive, 2, 0, 30, 0
+	port := ONTPort{Type: ONTPortETH, Number: 1}
+	err := executor.CreateServiceProfile(ServiceProfileRequest{
+		ProfileID:   20,
+		ProfileName: "BRIDGE",
+		ETHPorts:    &eth,
+		POTSPorts:   &pots,
+		CATVPorts:   &catv,
+		PortVlans:   []ServiceProfilePortVlan{{Port: port, Mode: PortVlanTranslation, Vlan: 200, UserVlan: &userVlan}},
+		NativeVlans: []ServiceProfileNativeVlan{{Port: port, Vlan: 100, Priority: &priority}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal, expected...)
+}
@@ -0,0 +1,443 @@
+package sshclient
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type ONTAuthMode string
+
+const (
+	ONTAuthSN         ONTAuthMode = "sn-auth"
+	ONTAuthPassword   ONTAuthMode = "password-auth"
+	ONTAuthLOID       ONTAuthMode = "loid-auth"
+	ONTAuthSNPassword ONTAuthMode = "sn-password-auth"
+)
+
+const maxDescriptionSize = 64
+
+type ONTManagementMode string
+
+const (
+	ONTManagementOMCI ONTManagementMode = "omci"
+	ONTManagementSNMP ONTManagementMode = "snmp"
+)
+
+var serialNumberRegexp = regexp.MustCompile(`^([0-9A-Fa-f]{16}|[A-Za-z0-9]{4}-?[0-9A-Fa-f]{8})$`)
+
+// AddONTRequest describes an "ont add" command. Optional numeric fields are
+// pointers because zero is a valid ONT and profile ID on the OLT. Password
+// and LOID authentication need exactly one of AlwaysOn and OnceOn.
+type AddONTRequest struct {
+	Port               int
+	ONTID              *int
+	AuthMode           ONTAuthMode
+	SerialNumber       string
+	Password           string
+	LOID               string
+	CheckCode          string
+	AlwaysOn           bool
+	OnceOn             bool
+	ManagementMode     ONTManagementMode
+	LineProfileID      *int
+	LineProfileName    string
+	ServiceProfileID   *int
+	ServiceProfileName string
+	Description        string
+}
+
+func (r AddONTRequest) Validate() error {
+	if r.Port < 0 {
+		return InvalidRequestError{Field: "Port", Reason: "must not be negative"}
+	}
+	if r.ONTID != nil && (*r.ONTID < 0 || *r.ONTID > 255) {
+		return InvalidRequestError{Field: "ONTID", Reason: "must be between 0 and 255"}
+	}
+
+	switch r.AuthMode {
+	case ONTAuthSN, ONTAuthSNPassword:
+		if _, err := normalizeSerialNumber(r.SerialNumber); err != nil {
+			return err
+		}
+		if r.AuthMode == ONTAuthSNPassword && r.Password == "" {
+			return InvalidRequestError{Field: "Password", Reason: "is required for sn+password authentication"}
+		}
+		if r.AlwaysOn {
+			return InvalidRequestError{Field: "AlwaysOn", Reason: "is only valid with password or loid authentication"}
+		}
+		if r.OnceOn {
+			return InvalidRequestError{Field: "OnceOn", Reason: "is only valid with password or loid authentication"}
+		}
+	case ONTAuthPassword, ONTAuthLOID:
+		if r.AuthMode == ONTAuthPassword && r.Password == "" {
+			return InvalidRequestError{Field: "Password", Reason: "is required for password authentication"}
+		}
+		if r.AuthMode == ONTAuthLOID && r.LOID == "" {
+			return InvalidRequestError{Field: "LOID", Reason: "is required for loid authentication"}
+		}
+		if r.AlwaysOn == r.OnceOn {
+			return InvalidRequestError{Field: "AlwaysOn", Reason: "exactly one of AlwaysOn and OnceOn is required"}
+		}
+	default:
+		return InvalidRequestError{Field: "AuthMode", Reason: fmt.Sprintf("unsupported value %q", r.AuthMode)}
+	}
+
+	for field, value := range map[string]string{"Password": r.Password, "LOID": r.LOID, "CheckCode": r.CheckCode} {
+		if strings.ContainsAny(value, " \t\r\n\"") {
+			return InvalidRequestError{Field: field, Reason: "must not contain whitespace or quotes"}
+		}
+	}
+
+	switch r.ManagementMode {
+	case "", ONTManagementOMCI:
+		if r.LineProfileID == nil && r.LineProfileName == "" {
+			return InvalidRequestError{Field: "LineProfileID", Reason: "a line profile is required for omci management"}
+		}
+		if r.ServiceProfileID == nil && r.ServiceProfileName == "" {
+			return InvalidRequestError{Field: "ServiceProfileID", Reason: "a service profile is required for omci management"}
+		}
+	case ONTManagementSNMP:
+	default:
+		return InvalidRequestError{Field: "ManagementMode", Reason: fmt.Sprintf("unsupported value %q", r.ManagementMode)}
+	}
+
+	if r.LineProfileID != nil && r.LineProfileName != "" {
+		return InvalidRequestError{Field: "LineProfileName", Reason: "cannot be combined with LineProfileID"}
+	}
+	if r.ServiceProfileID != nil && r.ServiceProfileName != "" {
+		return InvalidRequestError{Field: "ServiceProfileName", Reason: "cannot be combined with ServiceProfileID"}
+	}
+	if err := validateName("LineProfileName", r.LineProfileName); err != nil {
+		return err
+	}
+	if err := validateName("ServiceProfileName", r.ServiceProfileName); err != nil {
+		return err
+	}
+
+	return validateDescription(r.Description)
+}
+
+func (r AddONTRequest) command() (string, error) {
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+
+	parts := []string{"ont add", fmt.Sprint(r.Port)}
+	if r.ONTID != nil {
+		parts = append(parts, fmt.Sprint(*r.ONTID))
+	}
+
+	switch r.AuthMode {
+	case ONTAuthSN:
+		sn, _ := normalizeSerialNumber(r.SerialNumber)
+		parts = append(parts, "sn-auth", sn)
+	case ONTAuthSNPassword:
+		sn, _ := normalizeSerialNumber(r.SerialNumber)
+		parts = append(parts, "sn-auth", sn, "password-auth", r.Password)
+	case ONTAuthPassword:
+		parts = append(parts, "password-auth", r.Password)
+	case ONTAuthLOID:
+		parts = append(parts, "loid-auth", r.LOID)
+		if r.CheckCode != "" {
+			parts = append(parts, "checkcode", r.CheckCode)
+		}
+	}
+	if r.AlwaysOn {
+		parts = append(parts, "always-on")
+	} else if r.OnceOn {
+		parts = append(parts, "once-on")
+	}
+
+	if r.ManagementMode == "" {
+		parts = append(parts, string(ONTManagementOMCI))
+	} else {
+		parts = append(parts, string(r.ManagementMode))
+	}
+
+	if r.LineProfileID != nil {
+		parts = append(parts, "ont-lineprofile-id", fmt.Sprint(*r.LineProfileID))
+	} else if r.LineProfileName != "" {
+		parts = append(parts, "ont-lineprofile-name", quote(r.LineProfileName))
+	}
+
+	if r.ServiceProfileID != nil {
+		parts = append(parts, "ont-srvprofile-id", fmt.Sprint(*r.ServiceProfileID))
+	} else if r.ServiceProfileName != "" {
+		parts = append(parts, "ont-srvprofile-name", quote(r.ServiceProfileName))
+	}
+
+	if r.Description != "" {
+		parts = append(parts, "desc", quote(r.Description))
+	}
+
+	return strings.Join(parts, " "), nil
+}
+
+func normalizeSerialNumber(sn string) (string, error) {
+	sn = strings.TrimSpace(sn)
+	if fields := strings.Fields(sn); len(fields) > 0 {
+		sn = fields[0]
+	}
+	if !serialNumberRegexp.MatchString(sn) {
+		return "", InvalidSerialNumberError{}
+	}
+	return sn, nil
+}
+
+func validateDescription(description string) error {
+	if len(description) > maxDescriptionSize {
+		return InvalidRequestError{Field: "Description", Reason: fmt.Sprintf("must be at most %d characters", maxDescriptionSize)}
+	}
+	if strings.ContainsAny(description, "\"\r\n?") {
+		return InvalidRequestError{Field: "Description", Reason: "must not contain quotes, line breaks or '?'"}
+	}
+	return nil
+}
+
+func validateName(field, name string) error {
+	if strings.ContainsAny(name, "\"\r\n?") {
+		return InvalidRequestError{Field: field, Reason: "must not contain quotes, line breaks or '?'"}
+	}
+	return nil
+}
+
+func quote(value string) string {
+	return "\"" + value + "\""
+}
+
+type UserVlanMode string
+
+const (
+	UserVlanTagged         UserVlanMode = "tagged"
+	UserVlanUntagged       UserVlanMode = "untagged"
+	UserVlanPriorityTagged UserVlanMode = "priority-tagged"
+)
+
+type TagTransform string
+
+const (
+	TagTransformTranslate       TagTransform = "translate"
+	TagTransformTranslateAndAdd TagTransform = "translate-and-add"
+	TagTransformTransparent     TagTransform = "transparent"
+	TagTransformDefault         TagTransform = "default"
+)
+
+// ServicePortSpec describes a GPON "service-port" command. When Index is nil
+// the OLT assigns the index.
+type ServicePortSpec struct {
+	Index                     *int
+	Vlan                      int
+	Frame                     int
+	Slot                      int
+	Port                      int
+	ONTID                     int
+	GemPort                   int
+	UserVlanMode              UserVlanMode
+	UserVlan                  int
+	TagTransform              TagTransform
+	InnerVlan                 int
+	InnerPriority             *int
+	InboundTrafficTableIndex  *int
+	InboundTrafficTableName   string
+	OutboundTrafficTableIndex *int
+	OutboundTrafficTableName  string
+}
+
+func (s ServicePortSpec) Validate() error {
+	if s.Index != nil && *s.Index < 0 {
+		return InvalidRequestError{Field: "Index", Reason: "must not be negative"}
+	}
+	if err := validateVlan("Vlan", s.Vlan); err != nil {
+		return err
+	}
+	if s.Frame < 0 || s.Slot < 0 || s.Port < 0 {
+		return InvalidRequestError{Field: "Port", Reason: "frame, slot and port must not be negative"}
+	}
+	if s.ONTID < 0 || s.ONTID > 255 {
+		return InvalidRequestError{Field: "ONTID", Reason: "must be between 0 and 255"}
+	}
+	if s.GemPort < 0 || s.GemPort > 1023 {
+		return InvalidRequestError{Field: "GemPort", Reason: "must be between 0 and 1023"}
+	}
+
+	switch s.UserVlanMode {
+	case "", UserVlanTagged:
+		if err := validateVlan("UserVlan", s.UserVlan); err != nil {
+			return err
+		}
+	case UserVlanUntagged, UserVlanPriorityTagged:
+	default:
+		return InvalidRequestError{Field: "UserVlanMode", Reason: fmt.Sprintf("unsupported value %q", s.UserVlanMode)}
+	}
+
+	switch s.TagTransform {
+	case "", TagTransformTranslate, TagTransformTransparent, TagTransformDefault:
+	case TagTransformTranslateAndAdd:
+		if err := validateVlan("InnerVlan", s.InnerVlan); err != nil {
+			return err
+		}
+	default:
+		return InvalidRequestError{Field: "TagTransform", Reason: fmt.Sprintf("unsupported value %q", s.TagTransform)}
+	}
+	if s.InnerPriority != nil {
+		if s.TagTransform != TagTransformTranslateAndAdd {
+			return InvalidRequestError{Field: "InnerPriority", Reason: "is only valid with translate-and-add"}
+		}
+		if err := validatePriority("InnerPriority", *s.InnerPriority); err != nil {
+			return err
+		}
+	}
+
+	if err := validateTrafficTable("InboundTrafficTable", s.InboundTrafficTableIndex, s.InboundTrafficTableName); err != nil {
+		return err
+	}
+	return validateTrafficTable("OutboundTrafficTable", s.OutboundTrafficTableIndex, s.OutboundTrafficTableName)
+}
+
+func (s ServicePortSpec) command() (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+
+	parts := []string{"service-port"}
+	if s.Index != nil {
+		parts = append(parts, fmt.Sprint(*s.Index))
+	}
+	parts = append(parts,
+		"vlan", fmt.Sprint(s.Vlan),
+		"gpon", fmt.Sprintf("%d/%d/%d", s.Frame, s.Slot, s.Port),
+		"ont", fmt.Sprint(s.ONTID),
+		"gemport", fmt.Sprint(s.GemPort),
+		"multi-service", "user-vlan",
+	)
+
+	switch s.UserVlanMode {
+	case UserVlanUntagged, UserVlanPriorityTagged:
+		parts = append(parts, string(s.UserVlanMode))
+	default:
+		parts = append(parts, fmt.Sprint(s.UserVlan))
+	}
+
+	tagTransform := s.TagTransform
+	if tagTransform == "" {
+		tagTransform = TagTransformTranslate
+	}
+	parts = append(parts, "tag-transform", string(tagTransform))
+	if tagTransform == TagTransformTranslateAndAdd {
+		parts = append(parts, "inner-vlan", fmt.Sprint(s.InnerVlan))
+		if s.InnerPriority != nil {
+			parts = append(parts, "inner-priority", fmt.Sprint(*s.InnerPriority))
+		}
+	}
+
+	parts = append(parts, "inbound", trafficTableArgument(s.InboundTrafficTableIndex, s.InboundTrafficTableName))
+	parts = append(parts, "outbound", trafficTableArgument(s.OutboundTrafficTableIndex, s.OutboundTrafficTableName))
+
+	return strings.Join(parts, " "), nil
+}
+
+func validateVlan(field string, vlan int) error {
+	if vlan < 1 || vlan > 4095 {
+		return InvalidRequestError{Field: field, Reason: "must be between 1 and 4095"}
+	}
+	return nil
+}
+
+func validatePriority(field string, priority int) error {
+	if priority < 0 || priority > 7 {
+		return InvalidRequestError{Field: field, Reason: "must be between 0 and 7"}
+	}
+	return nil
+}
+
+func validateTrafficTable(field string, index *int, name string) error {
+	if index == nil && name == "" {
+		return InvalidRequestError{Field: field, Reason: "an index or a name is required"}
+	}
+	if index != nil && name != "" {
+		return InvalidRequestError{Field: field, Reason: "index and name cannot be combined"}
+	}
+	if index != nil && *index < 0 {
+		return InvalidRequestError{Field: field, Reason: "index must not be negative"}
+	}
+	return validateName(field, name)
+}
+
+func trafficTableArgument(index *int, name string) string {
+	if index != nil {
+		return fmt.Sprintf("traffic-table index %d", *index)
+	}
+	return "traffic-table name " + quote(name)
+}
+
+type ONTPortType string
+
+// POTS and CATV ports only appear in the port counts of service profiles;
+// native VLANs are set on eth and iphost ports.
+const (
+	ONTPortETH    ONTPortType = "eth"
+	ONTPortIPHost ONTPortType = "iphost"
+	ONTPortPOTS   ONTPortType = "pots"
+	ONTPortCATV   ONTPortType = "catv"
+)
+
+// ONTPort identifies a user-side port of an ONT. Number is ignored for iphost.
+type ONTPort struct {
+	Type   ONTPortType
+	Number int
+}
+
+func (p ONTPort) Validate() error {
+	switch p.Type {
+	case ONTPortIPHost:
+		return nil
+	case ONTPortETH:
+		if p.Number < 1 {
+			return InvalidRequestError{Field: "ONTPort", Reason: "eth port number must be at least 1"}
+		}
+		return nil
+	default:
+		return InvalidRequestError{Field: "ONTPort", Reason: fmt.Sprintf("unsupported port type %q", p.Type)}
+	}
+}
+
+func (p ONTPort) String() string {
+	if p.Type == ONTPortIPHost {
+		return string(p.Type)
+	}
+	return fmt.Sprintf("%s %d", p.Type, p.Number)
+}
+
+// NativeVlanSpec describes an "ont port native-vlan" command run from
+// interface GPON mode.
+type NativeVlanSpec struct {
+	Port     int
+	ONTID    int
+	ONTPort  ONTPort
+	Vlan     int
+	Priority int
+}
+
+func (s NativeVlanSpec) Validate() error {
+	if s.Port < 0 {
+		return InvalidRequestError{Field: "Port", Reason: "must not be negative"}
+	}
+	if s.ONTID < 0 || s.ONTID > 255 {
+		return InvalidRequestError{Field: "ONTID", Reason: "must be between 0 and 255"}
+	}
+	if err := s.ONTPort.Validate(); err != nil {
+		return err
+	}
+	if err := validateVlan("Vlan", s.Vlan); err != nil {
+		return err
+	}
+	return validatePriority("Priority", s.Priority)
+}
+
+func (s NativeVlanSpec) command() (string, error) {
+	if err := s.Validate(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("ont port native-vlan %d %d %s vlan %d priority %d", s.Port, s.ONTID, s.ONTPort, s.Vlan, s.Priority), nil
+}
@@ -0,0 +1,132 @@
+package sshclient
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAddONTRequestCommand(t *testing.T) {
+	lineProfile, serviceProfile, ontID := 10, 20, 5
+
+	tests := []struct {
+		name    string
+		request AddONTRequest
+		command string
+	}{
+		{
+			name: "sn",
+			request: AddONTRequest{
+				Port:             1,
+				AuthMode:         ONTAuthSN,
+				SerialNumber:     "HWTC-0ABCDEF1",
+				LineProfileID:    &lineProfile,
+				ServiceProfileID: &serviceProfile,
+				Description:      "home office",
+			},
+			command: `ont add 1 sn-auth HWTC-0ABCDEF1 omci ont-lineprofile-id 10 ont-srvprofile-id 20 desc "home office"`,
+		},
+		{
+			name: "password always on",
+			request: AddONTRequest{
+				Port:               1,
+				ONTID:              &ontID,
+				AuthMode:           ONTAuthPassword,
+				Password:           "secret",
+				AlwaysOn:           true,
+				LineProfileName:    "ftth",
+				ServiceProfileName: "bridge",
+			},
+			command: `ont add 1 5 password-auth secret always-on omci ont-lineprofile-name "ftth" ont-srvprofile-name "bridge"`,
+		},
+		{
+			name: "loid",
+			request: AddONTRequest{
+				Port:             2,
+				AuthMode:         ONTAuthLOID,
+				LOID:             "user01",
+				CheckCode:        "abc",
+				OnceOn:           true,
+				LineProfileID:    &lineProfile,
+				ServiceProfileID: &serviceProfile,
+			},
+			command: `ont add 2 loid-auth user01 checkcode abc once-on omci ont-lineprofile-id 10 ont-srvprofile-id 20`,
+		},
+		{
+			name: "sn and password",
+			request: AddONTRequest{
+				Port:             3,
+				AuthMode:         ONTAuthSNPassword,
+				SerialNumber:     "485754430ABCDEF1",
+				Password:         "secret",
+				LineProfileID:    &lineProfile,
+				ServiceProfileID: &serviceProfile,
+			},
+			command: `ont add 3 sn-auth 485754430ABCDEF1 password-auth secret omci ont-lineprofile-id 10 ont-srvprofile-id 20`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			command, err := test.request.command()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if command != test.command {
+				t.Fatalf("got %q, want %q", command, test.command)
+			}
+		})
+	}
+}
+
+func TestAddONTRequestValidate(t *testing.T) {
+	lineProfile := 10
+
+	tests := []struct {
+		name    string
+		request AddONTRequest
+		field   string
+	}{
+		{"bad serial number", AddONTRequest{AuthMode: ONTAuthSN, SerialNumber: "nope", LineProfileID: &lineProfile, ServiceProfileName: "x"}, ""},
+		{"always on with sn", AddONTRequest{AuthMode: ONTAuthSN, SerialNumber: "HWTC0ABCDEF1", AlwaysOn: true, LineProfileID: &lineProfile, ServiceProfileName: "x"}, "AlwaysOn"},
+		{"missing password", AddONTRequest{AuthMode: ONTAuthPassword, AlwaysOn: true, LineProfileID: &lineProfile, ServiceProfileName: "x"}, "Password"},
+		{"missing sn+password password", AddONTRequest{AuthMode: ONTAuthSNPassword, SerialNumber: "HWTC0ABCDEF1", LineProfileID: &lineProfile, ServiceProfileName: "x"}, "Password"},
+		{"missing sn+password serial number", AddONTRequest{AuthMode: ONTAuthSNPassword, Password: "secret", LineProfileID: &lineProfile, ServiceProfileName: "x"}, ""},
+		{"once on with sn+password", AddONTRequest{AuthMode: ONTAuthSNPassword, SerialNumber: "HWTC0ABCDEF1", Password: "secret", OnceOn: true, LineProfileID: &lineProfile, ServiceProfileName: "x"}, "OnceOn"},
+		{"password without always on or once on", AddONTRequest{AuthMode: ONTAuthPassword, Password: "secret", LineProfileID: &lineProfile, ServiceProfileName: "x"}, "AlwaysOn"},
+		{"loid with always on and once on", AddONTRequest{AuthMode: ONTAuthLOID, LOID: "user01", AlwaysOn: true, OnceOn: true, LineProfileID: &lineProfile, ServiceProfileName: "x"}, "AlwaysOn"},
+		{"missing service profile", AddONTRequest{AuthMode: ONTAuthLOID, LOID: "user01", AlwaysOn: true, LineProfileID: &lineProfile}, "ServiceProfileID"},
+		{"unsupported auth mode", AddONTRequest{AuthMode: "mac-auth"}, "AuthMode"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.request.Validate()
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if test.field == "" {
+				return
+			}
+			var requestErr InvalidRequestError
+			if !errors.As(err, &requestErr) || requestErr.Field != test.field {
+				t.Fatalf("expected an invalid %s, got %v", test.field, err)
+			}
+		})
+	}
+}
+
+func TestNativeVlanSpecCommand(t *testing.T) {
+	command, err := NativeVlanSpec{Port: 0, ONTID: 1, ONTPort: ONTPort{Type: ONTPortETH, Number: 2}, Vlan: 300, Priority: 5}.command()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if command != "ont port native-vlan 0 1 eth 2 vlan 300 priority 5" {
+		t.Fatalf("unexpected command %q", command)
+	}
+
+	for _, port := range []ONTPort{{Type: ONTPortPOTS, Number: 1}, {Type: ONTPortETH}} {
+		if _, err := (NativeVlanSpec{ONTPort: port, Vlan: 20}).command(); err == nil {
+			t.Fatalf("expected %+v to be rejected", port)
+		}
+	}
+}
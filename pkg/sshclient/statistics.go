@@ -0,0 +1,216 @@
+package sshclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type ONTTraffic struct {
+	SampledAt          time.Time `json:"sampled_at"`
+	UpstreamRateKbps   *float64  `json:"upstream_rate_kbps"`
+	DownstreamRateKbps *float64  `json:"downstream_rate_kbps"`
+	UpstreamBytes      *uint64   `json:"upstream_bytes"`
+	DownstreamBytes    *uint64   `json:"downstream_bytes"`
+	UpstreamPackets    *uint64   `json:"upstream_packets"`
+	DownstreamPackets  *uint64   `json:"downstream_packets"`
+	UpstreamDropped    *uint64   `json:"upstream_dropped"`
+	DownstreamDropped  *uint64   `json:"downstream_dropped"`
+}
+
+type ONTTrafficDelta struct {
+	Interval           time.Duration `json:"interval"`
+	UpstreamBytes      *uint64       `json:"upstream_bytes"`
+	DownstreamBytes    *uint64       `json:"downstream_bytes"`
+	UpstreamRateKbps   *float64      `json:"upstream_rate_kbps"`
+	DownstreamRateKbps *float64      `json:"downstream_rate_kbps"`
+}
+
+// Delta compares t with an earlier sample of the same ONT and returns the
+// bytes transferred in between and the resulting average rates. Values are
+// nil when a sample lacks the counter or the counter went backwards.
+func (t ONTTraffic) Delta(previous ONTTraffic) ONTTrafficDelta {
+	delta := ONTTrafficDelta{
+		Interval:        t.SampledAt.Sub(previous.SampledAt),
+		UpstreamBytes:   counterDelta(t.UpstreamBytes, previous.UpstreamBytes),
+		DownstreamBytes: counterDelta(t.DownstreamBytes, previous.DownstreamBytes),
+	}
+	delta.UpstreamRateKbps = counterRateKbps(delta.UpstreamBytes, delta.Interval)
+	delta.DownstreamRateKbps = counterRateKbps(delta.DownstreamBytes, delta.Interval)
+	return delta
+}
+
+type ONTLineQuality struct {
+	SampledAt                  time.Time         `json:"sampled_at"`
+	UpstreamBIPErrors          *uint64           `json:"upstream_bip_errors"`
+	DownstreamBIPErrors        *uint64           `json:"downstream_bip_errors"`
+	UpstreamFECCorrected       *uint64           `json:"upstream_fec_corrected"`
+	DownstreamFECCorrected     *uint64           `json:"downstream_fec_corrected"`
+	UpstreamFECUncorrectable   *uint64           `json:"upstream_fec_uncorrectable"`
+	DownstreamFECUncorrectable *uint64           `json:"downstream_fec_uncorrectable"`
+	Counters                   map[string]uint64 `json:"counters"`
+}
+
+type ONTLineQualityDelta struct {
+	Interval                   time.Duration `json:"interval"`
+	UpstreamBIPErrors          *uint64       `json:"upstream_bip_errors"`
+	DownstreamBIPErrors        *uint64       `json:"downstream_bip_errors"`
+	UpstreamFECCorrected       *uint64       `json:"upstream_fec_corrected"`
+	DownstreamFECCorrected     *uint64       `json:"downstream_fec_corrected"`
+	UpstreamFECUncorrectable   *uint64       `json:"upstream_fec_uncorrectable"`
+	DownstreamFECUncorrectable *uint64       `json:"downstream_fec_uncorrectable"`
+}
+
+func (q ONTLineQuality) Delta(previous ONTLineQuality) ONTLineQualityDelta {
+	return ONTLineQualityDelta{
+		Interval:                   q.SampledAt.Sub(previous.SampledAt),
+		UpstreamBIPErrors:          counterDelta(q.UpstreamBIPErrors, previous.UpstreamBIPErrors),
+		DownstreamBIPErrors:        counterDelta(q.DownstreamBIPErrors, previous.DownstreamBIPErrors),
+		UpstreamFECCorrected:       counterDelta(q.UpstreamFECCorrected, previous.UpstreamFECCorrected),
+		DownstreamFECCorrected:     counterDelta(q.DownstreamFECCorrected, previous.DownstreamFECCorrected),
+		UpstreamFECUncorrectable:   counterDelta(q.UpstreamFECUncorrectable, previous.UpstreamFECUncorrectable),
+		DownstreamFECUncorrectable: counterDelta(q.DownstreamFECUncorrectable, previous.DownstreamFECUncorrectable),
+	}
+}
+
+func (c *CommandExecutor) GetONTTraffic(frame, slot, port, ontID int) (*ONTTraffic, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont traffic %d %d %d %d all", frame, slot, port, ontID), "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	traffic, err := ParseONTTraffic(output)
+	if err != nil {
+		return nil, err
+	}
+	traffic.SampledAt = time.Now()
+	return traffic, nil
+}
+
+func (c *CommandExecutor) GetONTLineQuality(port, ontID int) (*ONTLineQuality, error) {
+	if c.ExecutorContext.Level != 3 {
+		return nil, fmt.Errorf("not in interface gpon mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display statistics ont-line-quality %d %d", port, ontID), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	quality, err := ParseONTLineQuality(output)
+	if err != nil {
+		return nil, err
+	}
+	quality.SampledAt = time.Now()
+	return quality, nil
+}
+
+func ParseONTTraffic(output string) (*ONTTraffic, error) {
+	traffic := &ONTTraffic{}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		key, value, ok := splitKeyValue(trimmedLine)
+		if !ok {
+			continue
+		}
+
+		switch key {
+		case "uptraffic(kbps)":
+			traffic.UpstreamRateKbps = parseOptionalFloat(value)
+		case "downtraffic(kbps)":
+			traffic.DownstreamRateKbps = parseOptionalFloat(value)
+		case "upstreambytes":
+			traffic.UpstreamBytes = parseOptionalUint(value)
+		case "downstreambytes":
+			traffic.DownstreamBytes = parseOptionalUint(value)
+		case "upstreampackets":
+			traffic.UpstreamPackets = parseOptionalUint(value)
+		case "downstreampackets":
+			traffic.DownstreamPackets = parseOptionalUint(value)
+		case "upstreamdroppedpackets":
+			traffic.UpstreamDropped = parseOptionalUint(value)
+		case "downstreamdroppedpackets":
+			traffic.DownstreamDropped = parseOptionalUint(value)
+		}
+	}
+
+	return traffic, nil
+}
+
+// ParseONTLineQuality parses "display statistics ont-line-quality". Every
+// numeric counter is also kept in Counters under its normalized label.
+func ParseONTLineQuality(output string) (*ONTLineQuality, error) {
+	quality := &ONTLineQuality{Counters: map[string]uint64{}}
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		key, value, ok := splitKeyValue(trimmedLine)
+		if !ok {
+			continue
+		}
+
+		counter := parseOptionalUint(value)
+		if counter == nil {
+			continue
+		}
+		quality.Counters[key] = *counter
+
+		switch key {
+		case "upstreambiperrorcount":
+			quality.UpstreamBIPErrors = counter
+		case "downstreambiperrorcount":
+			quality.DownstreamBIPErrors = counter
+		case "upstreamfeccorrectedbytes":
+			quality.UpstreamFECCorrected = counter
+		case "downstreamfeccorrectedbytes":
+			quality.DownstreamFECCorrected = counter
+		case "upstreamfecuncorrectablecodewords":
+			quality.UpstreamFECUncorrectable = counter
+		case "downstreamfecuncorrectablecodewords":
+			quality.DownstreamFECUncorrectable = counter
+		}
+	}
+
+	return quality, nil
+}
+
+func parseOptionalUint(value string) *uint64 {
+	number, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &number
+}
+
+// counterDelta returns nil when either sample is missing and when the counter
+// went backwards, since a wrap cannot be told apart from a reset of the ONT
+// or of its statistics.
+func counterDelta(current, previous *uint64) *uint64 {
+	if current == nil || previous == nil || *current < *previous {
+		return nil
+	}
+	delta := *current - *previous
+	return &delta
+}
+
+func counterRateKbps(bytes *uint64, interval time.Duration) *float64 {
+	if bytes == nil || interval <= 0 {
+		return nil
+	}
+	rate := float64(*bytes) * 8 / 1000 / interval.Seconds()
+	return &rate
+}
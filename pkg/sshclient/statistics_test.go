@@ -0,0 +1,83 @@
+package sshclient
+
+import (
+	"testing"
+	"time"
+)
+
+func uint64Pointer(value uint64) *uint64 {
+	return &value
+}
+
+func TestParseONTTraffic(t *testing.T) {
+	traffic, err := ParseONTTraffic(readTestdata(t, "ont_traffic.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if traffic.UpstreamRateKbps == nil || *traffic.UpstreamRateKbps != 125 {
+		t.Fatalf("unexpected upstream rate %v", traffic.UpstreamRateKbps)
+	}
+	if traffic.DownstreamBytes == nil || *traffic.DownstreamBytes != 98234112034 {
+		t.Fatalf("unexpected downstream bytes %v", traffic.DownstreamBytes)
+	}
+	if traffic.UpstreamDropped == nil || *traffic.UpstreamDropped != 12 {
+		t.Fatalf("unexpected upstream drops %v", traffic.UpstreamDropped)
+	}
+}
+
+func TestParseONTLineQuality(t *testing.T) {
+	quality, err := ParseONTLineQuality(readTestdata(t, "ont_line_quality.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quality.DownstreamBIPErrors == nil || *quality.DownstreamBIPErrors != 17 {
+		t.Fatalf("unexpected downstream BIP errors %v", quality.DownstreamBIPErrors)
+	}
+	if quality.DownstreamFECUncorrectable == nil || *quality.DownstreamFECUncorrectable != 3 {
+		t.Fatalf("unexpected downstream FEC uncorrectable %v", quality.DownstreamFECUncorrectable)
+	}
+	if quality.Counters["upstreamhecerrorcount"] != 0 || len(quality.Counters) != 7 {
+		t.Fatalf("unexpected counters %v", quality.Counters)
+	}
+}
+
+func TestONTTrafficDelta(t *testing.T) {
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	previous := ONTTraffic{SampledAt: start, UpstreamBytes: uint64Pointer(1000), DownstreamBytes: uint64Pointer(5000)}
+	current := ONTTraffic{SampledAt: start.Add(10 * time.Second), UpstreamBytes: uint64Pointer(26000), DownstreamBytes: uint64Pointer(4000)}
+
+	delta := current.Delta(previous)
+	if delta.UpstreamBytes == nil || *delta.UpstreamBytes != 25000 {
+		t.Fatalf("unexpected upstream bytes %v", delta.UpstreamBytes)
+	}
+	if delta.UpstreamRateKbps == nil || *delta.UpstreamRateKbps != 20 {
+		t.Fatalf("unexpected upstream rate %v", delta.UpstreamRateKbps)
+	}
+	if delta.DownstreamBytes != nil || delta.DownstreamRateKbps != nil {
+		t.Fatalf("expected an unknown downstream delta after the counter went backwards, got %v", delta.DownstreamBytes)
+	}
+}
+
+func TestONTTrafficDeltaWithoutPrevious(t *testing.T) {
+	start := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	previous := ONTTraffic{SampledAt: start}
+	current := ONTTraffic{SampledAt: start.Add(time.Minute), UpstreamBytes: uint64Pointer(26000)}
+
+	delta := current.Delta(previous)
+	if delta.UpstreamBytes != nil || delta.UpstreamRateKbps != nil {
+		t.Fatalf("expected an unknown delta, got %+v", delta)
+	}
+}
+
+func TestONTLineQualityDelta(t *testing.T) {
+	previous := ONTLineQuality{UpstreamBIPErrors: uint64Pointer(4), DownstreamBIPErrors: uint64Pointer(20)}
+	current := ONTLineQuality{UpstreamBIPErrors: uint64Pointer(9), DownstreamBIPErrors: uint64Pointer(2)}
+
+	delta := current.Delta(previous)
+	if delta.UpstreamBIPErrors == nil || *delta.UpstreamBIPErrors != 5 {
+		t.Fatalf("unexpected upstream BIP delta %v", delta.UpstreamBIPErrors)
+	}
+	if delta.DownstreamBIPErrors != nil || delta.UpstreamFECCorrected != nil {
+		t.Fatalf("expected unknown deltas, got %+v", delta)
+	}
+}
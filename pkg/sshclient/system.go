@@ -0,0 +1,267 @@
+package sshclient
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Dialect identifies the product line of the OLT. Board-wide commands are
+// only run on the MA5800 dialect; the MA5600 one runs them port by port.
+type Dialect string
+
+const (
+	DialectUnknown Dialect = ""
+	DialectMA5600  Dialect = "ma5600"
+	DialectMA5800  Dialect = "ma5800"
+)
+
+// DefaultDialect is used when the product cannot be identified.
+const DefaultDialect = DialectMA5600
+
+// DialectFromProduct maps the PRODUCT reported by "display version" to a
+// dialect: MA5600T, MA5603T, MA5608T and MA5683T use the MA5600 dialect while
+// the MA5800 and EA5800 series use the MA5800 one.
+func DialectFromProduct(product string) Dialect {
+	product = strings.ToUpper(product)
+	switch {
+	case strings.HasPrefix(product, "MA5800"), strings.HasPrefix(product, "EA5800"):
+		return DialectMA5800
+	case strings.HasPrefix(product, "MA56"):
+		return DialectMA5600
+	default:
+		return DialectUnknown
+	}
+}
+
+type OLTInfo struct {
+	Product    string        `json:"product"`
+	Version    string        `json:"version"`
+	VRPVersion string        `json:"vrp_version"`
+	Patch      string        `json:"patch"`
+	Uptime     time.Duration `json:"uptime"`
+	Dialect    Dialect       `json:"dialect"`
+	Boards     []BoardHealth `json:"boards"`
+}
+
+type BoardHealth struct {
+	Frame       int      `json:"frame"`
+	Slot        int      `json:"slot"`
+	Name        string   `json:"name"`
+	CPUUsage    *float64 `json:"cpu_usage_percent"`
+	MemoryUsage *float64 `json:"memory_usage_percent"`
+	Temperature *float64 `json:"temperature_c"`
+}
+
+type SystemVersion struct {
+	Product    string `json:"product"`
+	Version    string `json:"version"`
+	VRPVersion string `json:"vrp_version"`
+	Patch      string `json:"patch"`
+}
+
+// GetOLTInfo combines "display version", "display sysuptime" and the CPU,
+// memory and temperature of the boards of the frame. These commands take a
+// single F/S, so each board costs three commands; boards that are not in a
+// normal state do not report them and are skipped. It also sets the dialect
+// of the executor from the reported product.
+func (c *CommandExecutor) GetOLTInfo(frame int) (*OLTInfo, error) {
+	version, err := c.GetSystemVersion()
+	if err != nil {
+		return nil, err
+	}
+
+	uptime, err := c.GetSystemUptime()
+	if err != nil {
+		return nil, err
+	}
+
+	boards, err := c.GetBoards(frame)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &OLTInfo{
+		Product:    version.Product,
+		Version:    version.Version,
+		VRPVersion: version.VRPVersion,
+		Patch:      version.Patch,
+		Uptime:     uptime,
+		Dialect:    c.Dialect,
+		Boards:     make([]BoardHealth, 0, len(boards)),
+	}
+
+	for _, board := range boards {
+		if !board.IsRunning() {
+			continue
+		}
+
+		health := BoardHealth{Frame: board.Frame, Slot: board.Slot, Name: board.Name}
+		location := fmt.Sprintf("%d/%d", board.Frame, board.Slot)
+
+		output, err := c.ExecuteCommand(fmt.Sprintf("display cpu %s", location), "(config)#")
+		if err != nil {
+			return nil, fmt.Errorf("failed to run command: %v", err)
+		}
+		health.CPUUsage = ParseOccupancy(output)
+
+		output, err = c.ExecuteCommand(fmt.Sprintf("display memory %s", location), "(config)#")
+		if err != nil {
+			return nil, fmt.Errorf("failed to run command: %v", err)
+		}
+		health.MemoryUsage = ParseOccupancy(output)
+
+		output, err = c.ExecuteCommand(fmt.Sprintf("display temperature %s", location), "(config)#")
+		if err != nil {
+			return nil, fmt.Errorf("failed to run command: %v", err)
+		}
+		health.Temperature = ParseTemperature(output)
+
+		info.Boards = append(info.Boards, health)
+	}
+
+	return info, nil
+}
+
+func (c *CommandExecutor) GetSystemVersion() (*SystemVersion, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand("display version", "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+
+	version, err := ParseSystemVersion(output)
+	if err != nil {
+		return nil, err
+	}
+	c.Dialect = dialectFromVersion(version)
+
+	return version, nil
+}
+
+func (c *CommandExecutor) GetSystemUptime() (time.Duration, error) {
+	if c.ExecutorContext.Level != 2 {
+		return 0, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand("display sysuptime", "(config)#")
+	if err != nil {
+		return 0, fmt.Errorf("failed to run command: %v", err)
+	}
+
+	err = parseLinesFailure(strings.Split(output, "\n"))
+	if err != nil {
+		return 0, err
+	}
+	uptime, ok := parseOnlineDuration(output)
+	if !ok {
+		return 0, fmt.Errorf("system uptime not found in command output")
+	}
+	return uptime, nil
+}
+
+// detectDialect runs "display version" once when the dialect of the session
+// is not known yet. An unidentified product is stored as DefaultDialect so the
+// command is not repeated.
+func (c *CommandExecutor) detectDialect(prompt string) (Dialect, error) {
+	if c.Dialect != DialectUnknown {
+		return c.Dialect, nil
+	}
+
+	output, err := c.ExecuteCommand("display version", prompt)
+	if err != nil {
+		return DialectUnknown, fmt.Errorf("failed to run command: %v", err)
+	}
+
+	version, err := ParseSystemVersion(output)
+	if err != nil {
+		c.Dialect = DefaultDialect
+		return c.Dialect, nil
+	}
+	c.Dialect = dialectFromVersion(version)
+
+	return c.Dialect, nil
+}
+
+func dialectFromVersion(version *SystemVersion) Dialect {
+	dialect := DialectFromProduct(version.Product)
+	if dialect == DialectUnknown {
+		return DefaultDialect
+	}
+	return dialect
+}
+
+func ParseSystemVersion(output string) (*SystemVersion, error) {
+	version := &SystemVersion{}
+	vrpRegexp := regexp.MustCompile(`VRP.*Version\s+([^\s,(]+)`)
+
+	for _, line := range strings.Split(output, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		if match := vrpRegexp.FindStringSubmatch(trimmedLine); match != nil {
+			version.VRPVersion = match[1]
+			continue
+		}
+
+		key, value, ok := splitKeyValue(trimmedLine)
+		if !ok {
+			continue
+		}
+		switch key {
+		case "version":
+			if version.Version == "" {
+				version.Version = value
+			}
+		case "patch":
+			if version.Patch == "" {
+				version.Patch = value
+			}
+		case "product":
+			if version.Product == "" {
+				version.Product = value
+			}
+		}
+	}
+
+	if version.Product == "" && version.Version == "" {
+		return nil, fmt.Errorf("version not found in command output")
+	}
+
+	return version, nil
+}
+
+// ParseOccupancy returns the first percentage printed by "display cpu" or
+// "display memory", or nil when the board does not report one.
+func ParseOccupancy(output string) *float64 {
+	if strings.Contains(output, "Failure: ") {
+		return nil
+	}
+	match := regexp.MustCompile(`(\d+(\.\d+)?)\s*%`).FindStringSubmatch(output)
+	if match == nil {
+		return nil
+	}
+	return parseOptionalFloat(match[1])
+}
+
+// ParseTemperature returns the board temperature printed by
+// "display temperature F/S", or nil when the board does not report one.
+func ParseTemperature(output string) *float64 {
+	if strings.Contains(output, "Failure: ") {
+		return nil
+	}
+	match := regexp.MustCompile(`(?i)temperature[^:\n]*:\s*(-?\d+(\.\d+)?)`).FindStringSubmatch(output)
+	if match == nil {
+		match = regexp.MustCompile(`(?m)(-?\d+(\.\d+)?)\s*\(?C\)?\s*\r?$`).FindStringSubmatch(output)
+	}
+	if match == nil {
+		return nil
+	}
+	return parseOptionalFloat(match[1])
+}
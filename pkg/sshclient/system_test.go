@@ -0,0 +1,84 @@
+package sshclient
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSystemVersion(t *testing.T) {
+	version, err := ParseSystemVersion(readTestdata(t, "display_version.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := SystemVersion{Product: "MA5600T", Version: "MA5600V800R013C00", VRPVersion: "5.160", Patch: "SPC100"}
+	if *version != expected {
+		t.Fatalf("got %+v, want %+v", *version, expected)
+	}
+}
+
+func TestDetectDialectCachesDefault(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display version": "  VERSION : V100R001C00\n  PRODUCT : UNKNOWN-OLT\n(config)#",
+	})
+
+	for i := 0; i < 2; i++ {
+		dialect, err := executor.detectDialect("(config)#")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dialect != DefaultDialect {
+			t.Fatalf("expected the default dialect, got %q", dialect)
+		}
+	}
+	assertCommands(t, terminal, "display version")
+}
+
+func TestGetOLTInfoSkipsBoardsThatAreNotRunning(t *testing.T) {
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		"display version":         readTestdata(t, "display_version.txt"),
+		"display sysuptime":       "  System up time: 25 day(s), 3 hour(s), 10 minute(s), 5 second(s)\n(config)#",
+		"display board 0":         readTestdata(t, "display_board_frame.txt"),
+		"display cpu 0/0":         "  CPU occupancy: 12%\n(config)#",
+		"display memory 0/0":      "  Memory occupancy: 45%\n(config)#",
+		"display temperature 0/0": "  The temperature of the board: 48C\n(config)#",
+	})
+
+	info, err := executor.GetOLTInfo(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCommands(t, terminal,
+		"display version",
+		"display sysuptime",
+		"display board 0",
+		"display cpu 0/0", "display memory 0/0", "display temperature 0/0",
+		"display cpu 0/1", "display memory 0/1", "display temperature 0/1",
+		"display cpu 0/7", "display memory 0/7", "display temperature 0/7",
+		"display cpu 0/9", "display memory 0/9", "display temperature 0/9",
+	)
+
+	if info.Dialect != DialectMA5600 || info.Uptime != 25*24*time.Hour+3*time.Hour+10*time.Minute+5*time.Second {
+		t.Fatalf("unexpected info %+v", info)
+	}
+	board := info.Boards[0]
+	if board.CPUUsage == nil || *board.CPUUsage != 12 || board.MemoryUsage == nil || *board.MemoryUsage != 45 || board.Temperature == nil || *board.Temperature != 48 {
+		t.Fatalf("unexpected board health %+v", board)
+	}
+	if info.Boards[1].CPUUsage != nil {
+		t.Fatalf("expected no CPU usage without output, got %v", *info.Boards[1].CPUUsage)
+	}
+}
+
+func TestParseBoard(t *testing.T) {
+	board, err := ParseBoard(readTestdata(t, "display_board_gpon.txt"), 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if board.Name != "H805GPFD" || board.Status != "Normal" || board.OnlineState != "Online" || !board.IsGPON() || !board.IsRunning() {
+		t.Fatalf("unexpected board %+v", board)
+	}
+	expected := BoardPort{FSP: "0/1/1", Port: 1, Type: "GPON", OpticalModuleStatus: "Online", LaserState: "Normal"}
+	if len(board.Ports) != 2 || board.Ports[1] != expected {
+		t.Fatalf("got %+v, want %+v", board.Ports, expected)
+	}
+}
@@ -0,0 +1,333 @@
+package sshclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type PriorityPolicy string
+
+const (
+	PriorityPolicyLocalSetting PriorityPolicy = "local-setting"
+	PriorityPolicyTagInPackage PriorityPolicy = "tag-In-Package"
+	PriorityPolicyTagInIngress PriorityPolicy = "tag-In-Ingress"
+)
+
+// TrafficTable is an IP traffic table. Rates are in kbit/s and burst sizes
+// in bytes; CIR and PIR are nil when the table has "cir off".
+type TrafficTable struct {
+	Index          int            `json:"index"`
+	Name           string         `json:"name"`
+	CIR            *int           `json:"cir"`
+	CBS            *int           `json:"cbs"`
+	PIR            *int           `json:"pir"`
+	PBS            *int           `json:"pbs"`
+	Priority       *int           `json:"priority"`
+	PriorityPolicy PriorityPolicy `json:"priority_policy"`
+	Referenced     bool           `json:"referenced"`
+}
+
+// TrafficTableRequest describes a "traffic table ip" command. A nil CIR
+// creates an unlimited table ("cir off"); CBS, PIR and PBS are optional and
+// left to the OLT defaults when nil.
+type TrafficTableRequest struct {
+	Index          *int
+	Name           string
+	CIR            *int
+	CBS            *int
+	PIR            *int
+	PBS            *int
+	Priority       int
+	PriorityPolicy PriorityPolicy
+}
+
+func (r TrafficTableRequest) Validate() error {
+	if r.Index == nil && r.Name == "" {
+		return InvalidRequestError{Field: "Index", Reason: "an index or a name is required"}
+	}
+	if r.Index != nil && *r.Index < 0 {
+		return InvalidRequestError{Field: "Index", Reason: "must not be negative"}
+	}
+	if err := validateName("Name", r.Name); err != nil {
+		return err
+	}
+
+	if r.CIR == nil {
+		if r.CBS != nil || r.PIR != nil || r.PBS != nil {
+			return InvalidRequestError{Field: "CIR", Reason: "is required when CBS, PIR or PBS is set"}
+		}
+	} else {
+		for field, value := range map[string]*int{"CIR": r.CIR, "CBS": r.CBS, "PIR": r.PIR, "PBS": r.PBS} {
+			if value != nil && *value < 0 {
+				return InvalidRequestError{Field: field, Reason: "must not be negative"}
+			}
+		}
+		if r.PIR != nil && *r.PIR < *r.CIR {
+			return InvalidRequestError{Field: "PIR", Reason: "must not be lower than CIR"}
+		}
+		if r.PBS != nil && r.PIR == nil {
+			return InvalidRequestError{Field: "PBS", Reason: "requires PIR"}
+		}
+	}
+
+	if err := validatePriority("Priority", r.Priority); err != nil {
+		return err
+	}
+	switch r.PriorityPolicy {
+	case "", PriorityPolicyLocalSetting, PriorityPolicyTagInPackage, PriorityPolicyTagInIngress:
+	default:
+		return InvalidRequestError{Field: "PriorityPolicy", Reason: fmt.Sprintf("unsupported value %q", r.PriorityPolicy)}
+	}
+
+	return nil
+}
+
+func (r TrafficTableRequest) parameters() string {
+	parts := make([]string, 0)
+	if r.CIR == nil {
+		parts = append(parts, "cir off")
+	} else {
+		parts = append(parts, "cir", fmt.Sprint(*r.CIR))
+		if r.CBS != nil {
+			parts = append(parts, "cbs", fmt.Sprint(*r.CBS))
+		}
+		if r.PIR != nil {
+			parts = append(parts, "pir", fmt.Sprint(*r.PIR))
+		}
+		if r.PBS != nil {
+			parts = append(parts, "pbs", fmt.Sprint(*r.PBS))
+		}
+	}
+
+	policy := r.PriorityPolicy
+	if policy == "" {
+		policy = PriorityPolicyLocalSetting
+	}
+	parts = append(parts, "priority", fmt.Sprint(r.Priority), "priority-policy", string(policy))
+
+	return strings.Join(parts, " ")
+}
+
+func (r TrafficTableRequest) command() (string, error) {
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+
+	parts := []string{"traffic table ip"}
+	if r.Index != nil {
+		parts = append(parts, "index", fmt.Sprint(*r.Index))
+	}
+	if r.Name != "" {
+		parts = append(parts, "name", quote(r.Name))
+	}
+	parts = append(parts, r.parameters())
+
+	return strings.Join(parts, " "), nil
+}
+
+func (r TrafficTableRequest) modifyCommand() (string, error) {
+	if r.Index == nil {
+		return "", InvalidRequestError{Field: "Index", Reason: "is required to modify a traffic table"}
+	}
+	if err := r.Validate(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("traffic table ip modify index %d %s", *r.Index, r.parameters()), nil
+}
+
+func (c *CommandExecutor) GetTrafficTables() ([]TrafficTable, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand("display traffic table ip from-index 0", "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseTrafficTables(output)
+}
+
+func (c *CommandExecutor) GetTrafficTable(index int) (*TrafficTable, error) {
+	return c.getTrafficTable(fmt.Sprintf("display traffic table ip index %d", index))
+}
+
+func (c *CommandExecutor) GetTrafficTableByName(name string) (*TrafficTable, error) {
+	return c.getTrafficTable(fmt.Sprintf("display traffic table ip name %s", quote(name)))
+}
+
+func (c *CommandExecutor) getTrafficTable(command string) (*TrafficTable, error) {
+	if c.ExecutorContext.Level != 2 {
+		return nil, fmt.Errorf("not in config mode")
+	}
+	output, err := c.ExecuteCommand(command, "(config)#")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseTrafficTable(output)
+}
+
+// CreateTrafficTable returns the index of the new table. When req.Index is
+// nil the OLT assigns it and the table is looked up by name.
+func (c *CommandExecutor) CreateTrafficTable(req TrafficTableRequest) (int, error) {
+	if c.ExecutorContext.Level != 2 {
+		return 0, fmt.Errorf("not in config mode")
+	}
+
+	command, err := req.command()
+	if err != nil {
+		return 0, err
+	}
+
+	output, err := c.ExecuteCommand(command, "(config)#")
+	if err != nil {
+		return 0, fmt.Errorf("failed to run command: %v", err)
+	}
+
+	err = parseLinesFailure(strings.Split(output, "\n"))
+	if err != nil {
+		return 0, err
+	}
+
+	if req.Index != nil {
+		return *req.Index, nil
+	}
+	table, err := c.GetTrafficTableByName(req.Name)
+	if err != nil {
+		return 0, err
+	}
+	return table.Index, nil
+}
+
+func (c *CommandExecutor) ModifyTrafficTable(req TrafficTableRequest) error {
+	if c.ExecutorContext.Level != 2 {
+		return fmt.Errorf("not in config mode")
+	}
+
+	command, err := req.modifyCommand()
+	if err != nil {
+		return err
+	}
+
+	output, err := c.ExecuteCommand(command, "(config)#")
+	if err != nil {
+		return fmt.Errorf("failed to run command: %v", err)
+	}
+	return parseLinesFailure(strings.Split(output, "\n"))
+}
+
+func (c *CommandExecutor) DeleteTrafficTable(index int) error {
+	if c.ExecutorContext.Level != 2 {
+		return fmt.Errorf("not in config mode")
+	}
+
+	output, err := c.ExecuteCommand(fmt.Sprintf("undo traffic table ip index %d", index), "(config)#")
+	if err != nil {
+		return fmt.Errorf("failed to run command: %v", err)
+	}
+	return parseLinesFailure(strings.Split(output, "\n"))
+}
+
+// ParseTrafficTables parses the "display traffic table ip" summary table.
+func ParseTrafficTables(output string) ([]TrafficTable, error) {
+	results := make([]TrafficTable, 0)
+	var header []string
+
+	for _, line := range strings.Split(output, "\n") {
+		err := parseFailure(line)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) > 0 && strings.EqualFold(fields[0], "TID") {
+			header = make([]string, len(fields))
+			for i, field := range fields {
+				header[i] = strings.ToUpper(field)
+			}
+			continue
+		}
+		if header == nil || len(fields) < len(header) || !isNumber(fields[0]) {
+			continue
+		}
+
+		value := func(name string) string {
+			index := columnIndex(header, name)
+			if index < 0 {
+				return "-"
+			}
+			return fields[index]
+		}
+
+		results = append(results, TrafficTable{
+			Index:          parseIntOrZero(fields[0]),
+			CIR:            parseOptionalInt(value("CIR")),
+			CBS:            parseOptionalInt(value("CBS")),
+			PIR:            parseOptionalInt(value("PIR")),
+			PBS:            parseOptionalInt(value("PBS")),
+			Priority:       parseOptionalInt(value("PRI")),
+			PriorityPolicy: parsePriorityPolicy(value("PRI-POLICY")),
+		})
+	}
+
+	return results, nil
+}
+
+// ParseTrafficTable parses "display traffic table ip index N".
+func ParseTrafficTable(output string) (*TrafficTable, error) {
+	table := &TrafficTable{}
+	found := false
+
+	for _, line := range strings.Split(output, "\n") {
+		if strings.Contains(line, "does not exist") {
+			return nil, TrafficTableNotFoundError{}
+		}
+		err := parseFailure(line)
+		if err != nil {
+			return nil, err
+		}
+
+		key, value, ok := splitKeyValue(line)
+		if !ok {
+			continue
+		}
+		number := strings.Fields(value + " -")[0]
+
+		switch key {
+		case "tdindex":
+			table.Index, err = strconv.Atoi(value)
+			found = err == nil
+		case "tdname":
+			table.Name = value
+		case "cir":
+			table.CIR = parseOptionalInt(number)
+		case "cbs":
+			table.CBS = parseOptionalInt(number)
+		case "pir":
+			table.PIR = parseOptionalInt(number)
+		case "pbs":
+			table.PBS = parseOptionalInt(number)
+		case "priority":
+			table.Priority = parseOptionalInt(value)
+		case "prioritypolicy":
+			table.PriorityPolicy = parsePriorityPolicy(value)
+		case "referencedstatus":
+			table.Referenced = strings.EqualFold(value, "used")
+		}
+	}
+
+	if !found {
+		return nil, TrafficTableNotFoundError{}
+	}
+	return table, nil
+}
+
+// parsePriorityPolicy maps the policy as printed by the OLT, such as
+// "local-Setting", to the constant used in commands.
+func parsePriorityPolicy(value string) PriorityPolicy {
+	for _, policy := range []PriorityPolicy{PriorityPolicyLocalSetting, PriorityPolicyTagInPackage, PriorityPolicyTagInIngress} {
+		if strings.EqualFold(value, string(policy)) {
+			return policy
+		}
+	}
+	return PriorityPolicy(value)
+}
@@ -0,0 +1,48 @@
+package sshclient
+
+import "testing"
+
+func TestParseTrafficTable(t *testing.T) {
+	table, err := ParseTrafficTable(readTestdata(t, "traffic_table.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Index != 12 || table.Name != "ftth_100M" || *table.CIR != 102400 || *table.CBS != 3278800 ||
+		*table.PIR != 204800 || *table.PBS != 6555600 || *table.Priority != 0 || table.PriorityPolicy != PriorityPolicyLocalSetting || !table.Referenced {
+		t.Fatalf("unexpected traffic table %+v", table)
+	}
+}
+
+func TestParseTrafficTables(t *testing.T) {
+	tables, err := ParseTrafficTables(readTestdata(t, "traffic_tables.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 3 {
+		t.Fatalf("expected 3 traffic tables, got %d", len(tables))
+	}
+	if tables[1].Index != 6 || tables[1].CIR != nil || tables[1].PIR != nil || tables[1].PriorityPolicy != PriorityPolicyLocalSetting {
+		t.Fatalf("expected an unlimited table, got %+v", tables[1])
+	}
+	if *tables[0].CIR != 1024 || *tables[0].PBS != 69536 || *tables[0].Priority != 6 || tables[0].PriorityPolicy != PriorityPolicyTagInPackage {
+		t.Fatalf("unexpected traffic table %+v", tables[0])
+	}
+}
+
+func TestCreateTrafficTableLooksUpIndexByName(t *testing.T) {
+	command := "traffic table ip name \"ftth_100M\" cir 102400 pir 204800 priority 0 priority-policy local-setting"
+	executor, terminal := newTestExecutor(ExecutorContext{Level: 2}, map[string]string{
+		command: "  Create traffic descriptor record successfully\n(config)#",
+		"display traffic table ip name \"ftth_100M\"": readTestdata(t, "traffic_table.txt"),
+	})
+
+	cir, pir := 102400, 204800
+	index, err := executor.CreateTrafficTable(TrafficTableRequest{Name: "ftth_100M", CIR: &cir, PIR: &pir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 12 {
+		t.Fatalf("expected index 12, got %d", index)
+	}
+	assertCommands(t, terminal, command, "display traffic table ip name \"ftth_100M\"")
+}
@@ -0,0 +1,209 @@
+package sshclient
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type ONTWanInfo struct {
+	Index          int    `json:"index"`
+	Name           string `json:"name"`
+	ServiceType    string `json:"service_type"`
+	ConnectionType string `json:"connection_type"`
+	IPv4Status     string `json:"ipv4_status"`
+	IPv4AccessType string `json:"ipv4_access_type"`
+	IPv4Address    string `json:"ipv4_address"`
+	SubnetMask     string `json:"subnet_mask"`
+	Gateway        string `json:"gateway"`
+	PrimaryDNS     string `json:"primary_dns"`
+	SecondaryDNS   string `json:"secondary_dns"`
+	IPv6Status     string `json:"ipv6_status"`
+	IPv6Address    string `json:"ipv6_address"`
+	IPv6Prefix     string `json:"ipv6_prefix"`
+	IPv6Gateway    string `json:"ipv6_gateway"`
+	IPv6PrimaryDNS string `json:"ipv6_primary_dns"`
+	Vlan           int    `json:"vlan"`
+	Priority       int    `json:"priority"`
+	MAC            string `json:"mac"`
+	PPPoEUsername  string `json:"pppoe_username"`
+}
+
+func (w *ONTWanInfo) IsRouted() bool {
+	return strings.Contains(strings.ToLower(w.ConnectionType), "rout")
+}
+
+func (w *ONTWanInfo) IsBridged() bool {
+	return strings.Contains(strings.ToLower(w.ConnectionType), "bridg")
+}
+
+type ONTIPConfig struct {
+	Index        int    `json:"index"`
+	Mode         string `json:"mode"`
+	IPAddress    string `json:"ip_address"`
+	SubnetMask   string `json:"subnet_mask"`
+	Gateway      string `json:"gateway"`
+	PrimaryDNS   string `json:"primary_dns"`
+	SecondaryDNS string `json:"secondary_dns"`
+	Vlan         int    `json:"vlan"`
+	Priority     int    `json:"priority"`
+	MAC          string `json:"mac"`
+}
+
+func (c *CommandExecutor) GetONTWanInfo(port, ontID int) ([]ONTWanInfo, error) {
+	if c.ExecutorContext.Level != 3 {
+		return nil, fmt.Errorf("not in interface gpon mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont wan-info %d %d", port, ontID), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseONTWanInfo(output)
+}
+
+func (c *CommandExecutor) GetONTIPConfig(port, ontID int) ([]ONTIPConfig, error) {
+	if c.ExecutorContext.Level != 3 {
+		return nil, fmt.Errorf("not in interface gpon mode")
+	}
+	output, err := c.ExecuteCommand(fmt.Sprintf("display ont ipconfig %d %d", port, ontID), fmt.Sprintf("(config-if-gpon-%d/%d)#", c.ExecutorContext.Frame, c.ExecutorContext.Slot))
+	if err != nil {
+		return nil, fmt.Errorf("failed to run command: %v", err)
+	}
+	return ParseONTIPConfig(output)
+}
+
+// ParseONTWanInfo parses "display ont wan-info P ONTID". Every WAN
+// connection starts with an "Index" line.
+func ParseONTWanInfo(output string) ([]ONTWanInfo, error) {
+	results := make([]ONTWanInfo, 0)
+
+	var wan *ONTWanInfo
+	for _, line := range strings.Split(output, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		key, value, ok := splitKeyValue(trimmedLine)
+		if !ok {
+			continue
+		}
+
+		if key == "index" {
+			if wan != nil {
+				results = append(results, *wan)
+			}
+			wan = &ONTWanInfo{Index: parseIntOrZero(value)}
+			continue
+		}
+		if wan == nil {
+			continue
+		}
+
+		fieldMap := map[string]*string{
+			"name":                 &wan.Name,
+			"servicetype":          &wan.ServiceType,
+			"connectiontype":       &wan.ConnectionType,
+			"ipv4connectionstatus": &wan.IPv4Status,
+			"ipv4accesstype":       &wan.IPv4AccessType,
+			"ipv4address":          &wan.IPv4Address,
+			"subnetmask":           &wan.SubnetMask,
+			"defaultgateway":       &wan.Gateway,
+			"ipv4primarydns":       &wan.PrimaryDNS,
+			"ipv4secondarydns":     &wan.SecondaryDNS,
+			"ipv6connectionstatus": &wan.IPv6Status,
+			"ipv6address":          &wan.IPv6Address,
+			"prefix":               &wan.IPv6Prefix,
+			"ipv6defaultgateway":   &wan.IPv6Gateway,
+			"ipv6primarydns":       &wan.IPv6PrimaryDNS,
+			"pppoeusername":        &wan.PPPoEUsername,
+		}
+
+		switch key {
+		case "managevlan":
+			wan.Vlan = parseIntOrZero(value)
+		case "managepriority":
+			wan.Priority = parseIntOrZero(value)
+		case "macaddress":
+			wan.MAC = normalizeMACOrKeep(value)
+		default:
+			if field, ok := fieldMap[key]; ok {
+				*field = value
+			}
+		}
+	}
+
+	if wan != nil {
+		results = append(results, *wan)
+	}
+
+	return results, nil
+}
+
+// ParseONTIPConfig parses "display ont ipconfig P ONTID". Every IP host
+// starts with an "ONT IP N information" line.
+func ParseONTIPConfig(output string) ([]ONTIPConfig, error) {
+	results := make([]ONTIPConfig, 0)
+	headerRegexp := regexp.MustCompile(`ONT IP (\d+)\s+information`)
+
+	var config *ONTIPConfig
+	for _, line := range strings.Split(output, "\n") {
+		trimmedLine := strings.TrimSpace(line)
+
+		err := parseFailure(trimmedLine)
+		if err != nil {
+			return nil, err
+		}
+
+		if match := headerRegexp.FindStringSubmatch(trimmedLine); match != nil {
+			if config != nil {
+				results = append(results, *config)
+			}
+			config = &ONTIPConfig{Index: parseIntOrZero(match[1])}
+			continue
+		}
+
+		key, value, ok := splitKeyValue(trimmedLine)
+		if !ok || config == nil {
+			continue
+		}
+
+		fieldMap := map[string]*string{
+			"ontip":         &config.IPAddress,
+			"ontipmask":     &config.SubnetMask,
+			"ontgateway":    &config.Gateway,
+			"ontprimarydns": &config.PrimaryDNS,
+			"ontslavedns":   &config.SecondaryDNS,
+			"ontconfigtype": &config.Mode,
+		}
+
+		switch key {
+		case "ontmanagevlan":
+			config.Vlan = parseIntOrZero(value)
+		case "ontmanagepriority":
+			config.Priority = parseIntOrZero(value)
+		case "ontmac":
+			config.MAC = normalizeMACOrKeep(value)
+		default:
+			if field, ok := fieldMap[key]; ok {
+				*field = value
+			}
+		}
+	}
+
+	if config != nil {
+		results = append(results, *config)
+	}
+
+	return results, nil
+}
+
+func normalizeMACOrKeep(value string) string {
+	mac, err := parseMAC(value)
+	if err != nil {
+		return value
+	}
+	return mac
+}
@@ -0,0 +1,70 @@
+package sshclient
+
+import "testing"
+
+func TestParseONTWanInfo(t *testing.T) {
+	wans, err := ParseONTWanInfo(readTestdata(t, "ont_wan_info.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wans) != 2 {
+		t.Fatalf("expected 2 WAN connections, got %d", len(wans))
+	}
+
+	expected := ONTWanInfo{
+		Index:          2,
+		Name:           "2_INTERNET_R_VID_100",
+		ServiceType:    "INTERNET",
+		ConnectionType: "IP routed",
+		IPv4Status:     "Connected",
+		IPv4AccessType: "PPPoE",
+		IPv4Address:    "100.64.12.7",
+		SubnetMask:     "255.255.255.255",
+		Gateway:        "100.64.0.1",
+		PrimaryDNS:     "1.1.1.1",
+		SecondaryDNS:   "8.8.8.8",
+		IPv6Status:     "Connected",
+		IPv6Address:    "2001:db8:1::10",
+		IPv6Prefix:     "2001:db8:100::/56",
+		IPv6Gateway:    "fe80::1",
+		IPv6PrimaryDNS: "2001:4860:4860::8888",
+		Vlan:           100,
+		MAC:            "00:e0:fc:12:34:57",
+		PPPoEUsername:  "customer@isp",
+	}
+	if wans[1] != expected {
+		t.Fatalf("got %+v, want %+v", wans[1], expected)
+	}
+	if !wans[0].IsRouted() || wans[0].Vlan != 4000 {
+		t.Fatalf("unexpected first WAN connection %+v", wans[0])
+	}
+}
+
+func TestParseONTIPConfig(t *testing.T) {
+	configs, err := ParseONTIPConfig(readTestdata(t, "ont_ipconfig.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 2 {
+		t.Fatalf("expected 2 IP hosts, got %d: %+v", len(configs), configs)
+	}
+
+	expected := ONTIPConfig{
+		Index:        0,
+		Mode:         "static",
+		IPAddress:    "192.168.100.2",
+		SubnetMask:   "255.255.255.0",
+		Gateway:      "192.168.100.1",
+		PrimaryDNS:   "192.168.100.1",
+		SecondaryDNS: "0.0.0.0",
+		Vlan:         20,
+		Priority:     5,
+		MAC:          "00:e0:fc:12:34:58",
+	}
+	if configs[0] != expected {
+		t.Fatalf("got %+v, want %+v", configs[0], expected)
+	}
+	if configs[1].Index != 1 || configs[1].Mode != "DHCP" || configs[1].Vlan != 30 {
+		t.Fatalf("unexpected second IP host %+v", configs[1])
+	}
+}